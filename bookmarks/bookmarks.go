@@ -0,0 +1,101 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"lds/config"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// FileName is the name of the bookmarks file, stored next to config.json.
+const FileName = "bookmarks.json"
+
+// Bookmarks maps bookmark names to directories. A name can be a single
+// character, which can then be jumped to with a single key press.
+type Bookmarks struct {
+	path    string
+	entries map[string]string
+}
+
+// Load reads the bookmarks file. A missing file is not an error, it just means
+// there are no bookmarks yet.
+func Load() (*Bookmarks, error) {
+	b := &Bookmarks{path: config.DataFile(FileName), entries: make(map[string]string)}
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, &b.entries); err != nil {
+		return b, fmt.Errorf("parsing %s: %w", b.path, err)
+	}
+	return b, nil
+}
+
+// Save writes the bookmarks back to disk.
+func (b *Bookmarks) Save() error {
+	data, err := json.MarshalIndent(b.entries, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, data, 0644)
+}
+
+// Names returns the bookmark names in sorted order.
+func (b *Bookmarks) Names() []string {
+	names := make([]string, 0, len(b.entries))
+	for name := range b.entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the directory bookmarked under name.
+func (b *Bookmarks) Get(name string) (string, bool) {
+	dir, ok := b.entries[name]
+	return dir, ok
+}
+
+// Set bookmarks dir under name, replacing any existing bookmark of that name.
+func (b *Bookmarks) Set(name, dir string) error {
+	if name == "" {
+		return fmt.Errorf("bookmark name cannot be empty")
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	b.entries[name] = absDir
+	return b.Save()
+}
+
+// Remove deletes the bookmark called name.
+func (b *Bookmarks) Remove(name string) error {
+	delete(b.entries, name)
+	return b.Save()
+}
+
+// Rename moves a bookmark to a new name.
+func (b *Bookmarks) Rename(oldName, newName string) error {
+	dir, ok := b.entries[oldName]
+	if !ok {
+		return fmt.Errorf("no bookmark named %q", oldName)
+	}
+	if newName == "" {
+		return fmt.Errorf("bookmark name cannot be empty")
+	}
+	if _, exists := b.entries[newName]; exists {
+		return fmt.Errorf("a bookmark named %q already exists", newName)
+	}
+	delete(b.entries, oldName)
+	b.entries[newName] = dir
+	return b.Save()
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"fmt"
+	"lds/bookmarks"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const Usage = `Usage: lds [OPTION]... [PATH|@BOOKMARK]...
+       lds init bash|zsh|fish
+       lds import zoxide|autojump [FILE]
+
+Without options lds starts the interactive file browser in PATH (or the
+current directory). Listing options, several paths, or a stdout that is not a
+terminal print an ls style listing instead.
+
+Listing options:
+  -1               list one entry per line
+  -l               use the long listing format
+  -a, --all        do not hide entries starting with .
+  -r, --reverse    reverse the sort order
+  -S               sort by size, largest first
+  -t               sort by modification time, newest first
+  -X               sort by extension
+  -R, --recursive  list subdirectories recursively
+      --sort=MODE  sort by name, size, time or extension
+      --color=WHEN color the output: always, auto or never (default auto)
+
+Machine readable output:
+      --json         print the metadata of every entry as a JSON array
+      --ndjson       print one JSON object per line while listing
+      --fields=LIST  only include the comma separated fields in LIST
+
+Shell integration:
+      --choosedir=FILE  write the last visited directory to FILE on exit
+      --print-cwd       print the last visited directory on exit
+  lds init SHELL        print a wrapper function that cds into the last
+                        visited directory when lds exits
+
+Directory jumping:
+  lds import zoxide [FILE]    import directories from zoxide, reading the
+                              output of 'zoxide query --list --score' from
+                              FILE or running zoxide when FILE is omitted
+  lds import autojump [FILE]  import directories from an autojump.txt file
+
+Picker mode:
+      --pick        choose entries and print their absolute paths on exit
+      --multi       allow marking several entries with Space
+  -0, --null        separate the printed paths with NUL instead of newlines
+      --dirs-only   only allow directories to be picked
+      --files-only  only allow files to be picked
+
+  -h, --help       show this help
+      --help-keys  show the key bindings, including those set in the config
+`
+
+// Options holds the parsed command line.
+type Options struct {
+	Paths      []string
+	Long       bool
+	OnePerLine bool
+	All        bool
+	SortBy     string
+	Reverse    bool
+	Color      string
+	Recursive  bool
+	JSON       bool
+	NDJSON     bool
+	Fields     []string
+	ChooseDir  string
+	PrintCwd   bool
+	Pick       bool
+	Multi      bool
+	Null       bool
+	DirsOnly   bool
+	FilesOnly  bool
+	Help       bool
+	HelpKeys   bool
+
+	// Command and CommandArgs hold a subcommand such as `lds init bash`.
+	Command     string
+	CommandArgs []string
+
+	// Listing is set when an option was given that only makes sense for
+	// non-interactive output.
+	Listing bool
+}
+
+// Parse parses the command line arguments, not including the program name.
+// Short options can be combined as in `lds -la`.
+func Parse(args []string) (*Options, error) {
+	opts := &Options{Color: "auto"}
+
+	if len(args) > 0 && isCommand(args[0]) {
+		opts.Command = args[0]
+		opts.CommandArgs = args[1:]
+		return opts, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			opts.Paths = append(opts.Paths, args[i+1:]...)
+			return opts, nil
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			// value returns the option argument, either after "=" or as the next argument
+			next := func() (string, error) {
+				if hasValue {
+					return value, nil
+				}
+				if i+1 >= len(args) {
+					return "", fmt.Errorf("option --%s requires an argument", name)
+				}
+				i++
+				return args[i], nil
+			}
+			if err := parseLong(opts, name, hasValue, next); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, r := range arg[1:] {
+				if err := parseShort(opts, r); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			opts.Paths = append(opts.Paths, arg)
+		}
+	}
+
+	return opts, nil
+}
+
+func parseShort(opts *Options, r rune) error {
+	switch r {
+	case '1':
+		opts.OnePerLine = true
+	case 'l':
+		opts.Long = true
+	case 'a':
+		opts.All = true
+	case 'r':
+		opts.Reverse = true
+	case 'S':
+		opts.SortBy = "size"
+	case 't':
+		opts.SortBy = "time"
+	case 'X':
+		opts.SortBy = "extension"
+	case 'R':
+		opts.Recursive = true
+	case '0':
+		opts.Null = true
+		return nil
+	case 'h':
+		opts.Help = true
+		return nil
+	default:
+		return fmt.Errorf("invalid option -- '%c'", r)
+	}
+	opts.Listing = true
+	return nil
+}
+
+func parseLong(opts *Options, name string, hasValue bool, next func() (string, error)) error {
+	switch name {
+	case "all":
+		opts.All = true
+	case "reverse":
+		opts.Reverse = true
+	case "sort":
+		value, err := next()
+		if err != nil {
+			return err
+		}
+		if value != "name" && value != "size" && value != "time" && value != "extension" {
+			return fmt.Errorf("invalid argument '%s' for --sort", value)
+		}
+		opts.SortBy = value
+	case "color":
+		opts.Color = "always"
+		if hasValue {
+			value, _ := next()
+			if value != "always" && value != "auto" && value != "never" {
+				return fmt.Errorf("invalid argument '%s' for --color", value)
+			}
+			opts.Color = value
+		}
+	case "recursive":
+		opts.Recursive = true
+	case "json":
+		opts.JSON = true
+	case "ndjson":
+		opts.NDJSON = true
+	case "fields":
+		value, err := next()
+		if err != nil {
+			return err
+		}
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+		if err := validateFields(opts.Fields); err != nil {
+			return err
+		}
+	case "choosedir":
+		value, err := next()
+		if err != nil {
+			return err
+		}
+		opts.ChooseDir = value
+		return nil
+	case "print-cwd":
+		opts.PrintCwd = true
+		return nil
+	case "pick":
+		opts.Pick = true
+		return nil
+	case "multi":
+		opts.Multi = true
+		return nil
+	case "null":
+		opts.Null = true
+		return nil
+	case "dirs-only":
+		opts.DirsOnly = true
+		return nil
+	case "files-only":
+		opts.FilesOnly = true
+		return nil
+	case "help":
+		opts.Help = true
+		return nil
+	case "help-keys":
+		opts.HelpKeys = true
+		return nil
+	default:
+		return fmt.Errorf("unrecognized option '--%s'", name)
+	}
+	opts.Listing = true
+	return nil
+}
+
+// ResolveBookmarks replaces @name arguments with the directory bookmarked under
+// name. Paths that exist on disk are left alone, even if they start with @.
+func ResolveBookmarks(opts *Options) error {
+	var marks *bookmarks.Bookmarks
+	for i, path := range opts.Paths {
+		name, ok := strings.CutPrefix(path, "@")
+		if !ok || name == "" {
+			continue
+		}
+		if _, err := os.Lstat(path); err == nil {
+			continue
+		}
+		if marks == nil {
+			var err error
+			if marks, err = bookmarks.Load(); err != nil {
+				return err
+			}
+		}
+		dir, ok := marks.Get(name)
+		if !ok {
+			return fmt.Errorf("no bookmark named %q", name)
+		}
+		opts.Paths[i] = dir
+	}
+	return nil
+}
+
+func isCommand(arg string) bool {
+	return arg == "init" || arg == "import"
+}
+
+// ShouldList reports whether lds should print a listing instead of starting the
+// interactive interface. --print-cwd and the picker options always start the
+// interface since their stdout is usually captured by the shell. --choosedir
+// does not, so that the wrapper from `lds init` still lists when piped.
+func (opts *Options) ShouldList() bool {
+	if opts.Listing || len(opts.Paths) > 1 {
+		return true
+	}
+	if len(opts.Paths) == 1 {
+		info, err := os.Stat(opts.Paths[0])
+		if err != nil || !info.IsDir() {
+			return true
+		}
+	}
+	if opts.PrintCwd || opts.Pick {
+		return false
+	}
+	return !IsTerminal(os.Stdout)
+}
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"lds/config"
+	"lds/frecency"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Import merges the directories known to zoxide or autojump into the frecency
+// database, aging it the way cfg configures. args are the arguments following
+// `lds import`.
+func Import(cfg *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: lds import zoxide|autojump [FILE]")
+	}
+	source, file := args[0], ""
+	if len(args) == 2 {
+		file = args[1]
+	}
+
+	var entries []frecency.Entry
+	var err error
+	switch source {
+	case "zoxide":
+		var r io.Reader
+		if r, err = zoxideData(file); err == nil {
+			entries, err = frecency.ParseZoxide(r)
+		}
+	case "autojump":
+		if file == "" {
+			file = autojumpDataFile()
+		}
+		var f *os.File
+		if f, err = os.Open(file); err == nil {
+			defer f.Close()
+			entries, err = frecency.ParseAutojump(f)
+		}
+	default:
+		return fmt.Errorf("unknown import source %q, expected zoxide or autojump", source)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := frecency.Open(cfg.Frecency.MaxAge).Import(entries); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d directories from %s\n", len(entries), source)
+	return nil
+}
+
+// zoxideData returns the scored directory list, from file when given or else
+// from the zoxide binary itself since its database is in a binary format.
+func zoxideData(file string) (io.Reader, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		return bytes.NewReader(data), err
+	}
+	output, err := exec.Command("zoxide", "query", "--list", "--score").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running zoxide: %w", err)
+	}
+	return bytes.NewReader(output), nil
+}
+
+func autojumpDataFile() string {
+	homeDir, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "autojump", "autojump.txt")
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "autojump", "autojump.txt")
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "autojump", "autojump.txt")
+	}
+	return filepath.Join(homeDir, ".local", "share", "autojump", "autojump.txt")
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lds/config"
+	"lds/utils"
+	"os"
+	"slices"
+	"strings"
+)
+
+// PrintJSON writes the metadata of the paths in opts to w, either as a single
+// JSON array or, with --ndjson, as one object per line. Records are written as
+// soon as a directory has been read so large trees stream instead of being
+// collected in memory first.
+func PrintJSON(w io.Writer, cfg *config.Config, opts *Options) error {
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = cfg.Sort.By
+	}
+	reverse := opts.Reverse != cfg.Sort.Reverse
+
+	out := &jsonWriter{w: w, fields: opts.Fields, ndjson: opts.NDJSON}
+	out.begin()
+
+	var lastErr error
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lds: cannot access '%s': %v\n", path, err)
+			lastErr = err
+			continue
+		}
+		if !info.IsDir() {
+			file, err := utils.StatFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "lds: cannot access '%s': %v\n", path, err)
+				lastErr = err
+				continue
+			}
+			if err := out.write(file); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+		if err := out.writeDirectory(path, opts, sortBy, reverse); err != nil {
+			lastErr = err
+		}
+	}
+
+	out.end()
+	return lastErr
+}
+
+type jsonWriter struct {
+	w       io.Writer
+	fields  []string
+	ndjson  bool
+	written int
+}
+
+func (out *jsonWriter) begin() {
+	if !out.ndjson {
+		fmt.Fprint(out.w, "[")
+	}
+}
+
+func (out *jsonWriter) end() {
+	if out.ndjson {
+		return
+	}
+	if out.written > 0 {
+		fmt.Fprintln(out.w)
+	}
+	fmt.Fprintln(out.w, "]")
+}
+
+func (out *jsonWriter) writeDirectory(dir string, opts *Options, sortBy string, reverse bool) error {
+	entries, err := ListDirectory(dir, opts.All, sortBy, reverse, utils.AllDetails)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lds: cannot open directory '%s': %v\n", dir, err)
+		return err
+	}
+	for _, entry := range entries {
+		if err := out.write(entry); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Recursive {
+		return nil
+	}
+	var lastErr error
+	for _, entry := range entries {
+		if entry.FileType == "Directory" {
+			if err := out.writeDirectory(entry.Path, opts, sortBy, reverse); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+func (out *jsonWriter) write(file config.FileInfo) error {
+	record, err := encodeFields(file, out.fields)
+	if err != nil {
+		return err
+	}
+	switch {
+	case out.ndjson:
+		_, err = fmt.Fprintf(out.w, "%s\n", record)
+	case out.written == 0:
+		_, err = fmt.Fprintf(out.w, "\n  %s", record)
+	default:
+		_, err = fmt.Fprintf(out.w, ",\n  %s", record)
+	}
+	out.written++
+	return err
+}
+
+// encodeFields marshals file keeping only fields, in the order they were given.
+// With no fields the whole record is encoded.
+func encodeFields(file config.FileInfo, fields []string) ([]byte, error) {
+	data, err := json.Marshal(file)
+	if err != nil || len(fields) == 0 {
+		return data, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	var record bytes.Buffer
+	record.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			record.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		record.Write(key)
+		record.WriteByte(':')
+		record.Write(values[field])
+	}
+	record.WriteByte('}')
+	return record.Bytes(), nil
+}
+
+// validateFields checks the requested fields against the keys of a FileInfo
+// record.
+func validateFields(fields []string) error {
+	data, _ := json.Marshal(config.FileInfo{})
+	var known map[string]json.RawMessage
+	json.Unmarshal(data, &known)
+
+	var valid []string
+	for key := range known {
+		valid = append(valid, key)
+	}
+	slices.Sort(valid)
+	for _, field := range fields {
+		if _, ok := known[field]; !ok {
+			return fmt.Errorf("unknown field '%s', valid fields are: %s", field, strings.Join(valid, ", "))
+		}
+	}
+	return nil
+}
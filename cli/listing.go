@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"lds/config"
+	"lds/ui"
+	"lds/utils"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// PrintListing writes an ls style listing of the paths in opts to w. File
+// operands are listed first, followed by the contents of every directory. It
+// returns the last error encountered, after listing everything it could.
+func PrintListing(w io.Writer, cfg *config.Config, opts *Options) error {
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	out := newListingWriter(w, cfg, opts)
+
+	var fileOperands []config.FileInfo
+	var dirOperands []string
+	var lastErr error
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lds: cannot access '%s': %v\n", path, err)
+			lastErr = err
+			continue
+		}
+		if info.IsDir() {
+			dirOperands = append(dirOperands, path)
+			continue
+		}
+		file, err := utils.StatFileDetails(path, out.details)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lds: cannot access '%s': %v\n", path, err)
+			lastErr = err
+			continue
+		}
+		file.Name = path
+		fileOperands = append(fileOperands, file)
+	}
+
+	utils.SortFiles(fileOperands, out.sortBy, out.reverse)
+	out.printEntries(fileOperands)
+
+	for i, dir := range dirOperands {
+		if len(fileOperands) > 0 || i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := out.printDirectory(dir, len(paths) > 1 || opts.Recursive); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// printDirectory lists dir, preceded by a header line when showHeader is set,
+// and descends into its subdirectories when listing recursively.
+func (out *listingWriter) printDirectory(dir string, showHeader bool) error {
+	if showHeader {
+		fmt.Fprintf(out.w, "%s:\n", dir)
+	}
+	entries, err := ListDirectory(dir, out.opts.All, out.sortBy, out.reverse, out.details)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lds: cannot open directory '%s': %v\n", dir, err)
+		return err
+	}
+	out.printEntries(entries)
+
+	if !out.opts.Recursive {
+		return nil
+	}
+	var lastErr error
+	for _, entry := range entries {
+		if entry.FileType != "Directory" {
+			continue
+		}
+		fmt.Fprintln(out.w)
+		if err := out.printDirectory(entry.Path, true); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// ListDirectory reads dir and returns its entries sorted the way a listing shows
+// them, leaving out hidden entries unless all is set. Only the given details are
+// filled in.
+func ListDirectory(dir string, all bool, sortBy string, reverse bool, details utils.Details) ([]config.FileInfo, error) {
+	directories, regularFiles, hiddenFiles, err := utils.ReadDirectoryDetails(dir, details)
+	if err != nil {
+		return nil, err
+	}
+	entries := append(directories, regularFiles...)
+	if all {
+		entries = append(entries, hiddenFiles...)
+	}
+	utils.SortFiles(entries, sortBy, reverse)
+	return entries, nil
+}
+
+type listingWriter struct {
+	w          io.Writer
+	opts       *Options
+	columns    []string
+	details    utils.Details
+	colors     ui.LSColors
+	sortBy     string
+	reverse    bool
+	isTerminal bool
+	width      int
+}
+
+func newListingWriter(w io.Writer, cfg *config.Config, opts *Options) *listingWriter {
+	out := &listingWriter{w: w, opts: opts, width: 80}
+
+	if f, ok := w.(*os.File); ok && IsTerminal(f) {
+		out.isTerminal = true
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			out.width = width
+		}
+	}
+
+	out.columns = cfg.ListView.Columns
+	if len(out.columns) == 0 {
+		out.columns = ui.DefaultColumns
+	}
+	// Only the git column needs a detail that costs a subprocess per entry.
+	if opts.Long && slices.Contains(out.columns, "git") {
+		out.details = utils.GitStatusDetail
+	}
+
+	out.sortBy = opts.SortBy
+	if out.sortBy == "" {
+		out.sortBy = cfg.Sort.By
+	}
+	out.reverse = opts.Reverse != cfg.Sort.Reverse
+
+	if opts.Color == "always" || (opts.Color == "auto" && out.isTerminal) {
+		colorCfg := *cfg
+		colorCfg.LSColors.Enabled = true
+		out.colors = ui.LoadLSColors(&colorCfg)
+	}
+
+	return out
+}
+
+func (out *listingWriter) printEntries(entries []config.FileInfo) {
+	switch {
+	case out.opts.Long:
+		out.printLong(entries)
+	case out.opts.OnePerLine || !out.isTerminal:
+		for _, entry := range entries {
+			fmt.Fprintln(out.w, out.colorize(entry, entry.Name))
+		}
+	default:
+		out.printGrid(entries)
+	}
+}
+
+func (out *listingWriter) printLong(entries []config.FileInfo) {
+	widths := ui.ColumnWidths(entries, out.columns)
+	for _, entry := range entries {
+		cells := ui.FormatRow(entry, out.columns, widths, 1<<16)
+		for i, column := range out.columns {
+			if column == "name" {
+				cells[i] = out.colorize(entry, cells[i])
+				if entry.IsSymlink {
+					cells[i] += " -> " + entry.SymlinkTarget
+				}
+			}
+		}
+		fmt.Fprintln(out.w, strings.TrimRight(strings.Join(cells, " "), " "))
+	}
+}
+
+// printGrid prints the names in columns that are filled top to bottom, like
+// ls -C does.
+func (out *listingWriter) printGrid(entries []config.FileInfo) {
+	if len(entries) == 0 {
+		return
+	}
+	cellWidth := 0
+	for _, entry := range entries {
+		cellWidth = max(cellWidth, utf8.RuneCountInString(entry.Name)+2)
+	}
+	cols := max(out.width/cellWidth, 1)
+	rows := (len(entries) + cols - 1) / cols
+
+	for row := 0; row < rows; row++ {
+		var line strings.Builder
+		for col := 0; col < cols; col++ {
+			i := col*rows + row
+			if i >= len(entries) {
+				break
+			}
+			name := entries[i].Name
+			line.WriteString(out.colorize(entries[i], name))
+			if (col+1)*rows+row < len(entries) {
+				line.WriteString(strings.Repeat(" ", cellWidth-utf8.RuneCountInString(name)))
+			}
+		}
+		fmt.Fprintln(out.w, line.String())
+	}
+}
+
+func (out *listingWriter) colorize(entry config.FileInfo, text string) string {
+	code := out.colors.CodeFor(entry)
+	if code == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
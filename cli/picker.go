@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"io"
+)
+
+// WritePicked prints the paths chosen in picker mode, one per line or NUL
+// terminated with --null so that names containing newlines survive xargs -0.
+func WritePicked(w io.Writer, paths []string, null bool) error {
+	sep := "\n"
+	if null {
+		sep = "\x00"
+	}
+	for _, path := range paths {
+		if _, err := io.WriteString(w, path+sep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
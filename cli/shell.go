@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+const posixInit = `lds() {
+    local tmp dir
+    tmp="$(mktemp)" || return
+    command lds --choosedir "$tmp" "$@"
+    dir="$(cat "$tmp")"
+    rm -f "$tmp"
+    if [ -n "$dir" ] && [ -d "$dir" ] && [ "$dir" != "$PWD" ]; then
+        cd -- "$dir"
+    fi
+}
+`
+
+const fishInit = `function lds
+    set -l tmp (mktemp); or return
+    command lds --choosedir $tmp $argv
+    set -l dir (cat $tmp)
+    rm -f $tmp
+    if test -n "$dir" -a -d "$dir" -a "$dir" != "$PWD"
+        cd -- $dir
+    end
+end
+`
+
+// ShellInit returns the wrapper function for shell that makes quitting lds
+// leave the shell in the directory lds was last in. It is meant to be used
+// as `eval "$(lds init bash)"` or `lds init fish | source`.
+func ShellInit(shell string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh":
+		return posixInit, nil
+	case "fish":
+		return fishInit, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+}
+
+// WriteLastDirectory hands the current directory back to the calling shell as
+// requested by --choosedir and --print-cwd.
+func WriteLastDirectory(opts *Options) error {
+	if opts.ChooseDir == "" && !opts.PrintCwd {
+		return nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if opts.PrintCwd {
+		fmt.Println(dir)
+	}
+	if opts.ChooseDir != "" {
+		return os.WriteFile(opts.ChooseDir, []byte(dir), 0600)
+	}
+	return nil
+}
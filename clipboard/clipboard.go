@@ -0,0 +1,123 @@
+package clipboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"lds/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the shared clipboard file, stored next to
+// config.json.
+const FileName = "clipboard.json"
+
+// Clipboard holds the entries yanked or cut to be pasted in another
+// directory. A shared clipboard is kept in a file, so that every lds instance
+// pastes what any of them yanked last.
+type Clipboard struct {
+	Paths []string `json:"paths"`
+	// Cut makes pasting move the entries instead of copying them.
+	Cut bool `json:"cut"`
+
+	// path is the shared file, "" when the clipboard is not shared.
+	path    string
+	modTime time.Time
+}
+
+// New returns an empty clipboard, or the shared one when shared is set.
+func New(shared bool) (*Clipboard, error) {
+	c := &Clipboard{}
+	if !shared {
+		return c, nil
+	}
+	c.path = config.DataFile(FileName)
+	return c, c.Sync()
+}
+
+// Sync reads the shared file again when another instance has changed it
+// since it was last read or written.
+func (c *Clipboard) Sync() error {
+	if c.path == "" {
+		return nil
+	}
+	info, err := os.Stat(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var contents Clipboard
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return fmt.Errorf("parsing %s: %w", c.path, err)
+	}
+	c.Paths, c.Cut, c.modTime = contents.Paths, contents.Cut, info.ModTime()
+	return nil
+}
+
+func (c *Clipboard) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(c.path); err == nil {
+		c.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// Set replaces the contents of the clipboard with paths.
+func (c *Clipboard) Set(paths []string, cut bool) error {
+	c.Paths, c.Cut = paths, cut
+	return c.save()
+}
+
+// Clear empties the clipboard.
+func (c *Clipboard) Clear() error {
+	return c.Set(nil, false)
+}
+
+// Empty reports whether there is nothing to paste.
+func (c *Clipboard) Empty() bool {
+	return len(c.Paths) == 0
+}
+
+// Summary describes the contents for the status line, as in
+// "Clipboard, 2 to copy: a.txt, notes", or returns "" when it is empty.
+func (c *Clipboard) Summary() string {
+	if c.Empty() {
+		return ""
+	}
+	verb := "copy"
+	if c.Cut {
+		verb = "move"
+	}
+	names := make([]string, len(c.Paths))
+	for i, path := range c.Paths {
+		names[i] = filepath.Base(path)
+	}
+	return fmt.Sprintf("Clipboard, %d to %s: %s", len(c.Paths), verb, strings.Join(names, ", "))
+}
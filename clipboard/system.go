@@ -0,0 +1,70 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// tools are the programs that set the system clipboard from their input,
+// each with the environment variable that has to be set for it to work.
+var tools = []struct {
+	env  string
+	args []string
+}{
+	{"WAYLAND_DISPLAY", []string{"wl-copy"}},
+	{"DISPLAY", []string{"xclip", "-selection", "clipboard"}},
+	{"", []string{"pbcopy"}},
+	{"", []string{"clip.exe"}},
+}
+
+// Copy puts text on the system clipboard. It writes an OSC 52 escape
+// sequence to tty, which the terminal turns into a clipboard update even over
+// SSH. Without a tty, or when writing to it fails, text is handed to wl-copy,
+// xclip, pbcopy or clip instead, whichever is installed first. tty may be nil.
+func Copy(tty io.Writer, text string) error {
+	var errs []error
+	if tty != nil {
+		_, err := io.WriteString(tty, osc52(text))
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	for _, tool := range tools {
+		if tool.env != "" && os.Getenv(tool.env) == "" {
+			continue
+		}
+		path, err := exec.LookPath(tool.args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, tool.args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return errors.New("no terminal or clipboard tool to copy to")
+	}
+	return errors.Join(errs...)
+}
+
+// osc52 returns the escape sequence that sets the clipboard to text. Inside
+// tmux and screen it is wrapped so that they pass it on to the terminal.
+func osc52(text string) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + sequence + "\x1b\\"
+	}
+	return sequence
+}
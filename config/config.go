@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -25,19 +27,9 @@ type Config struct {
 		Left  string `json:"left"`
 		Right string `json:"right"`
 	} `json:"navigation"`
-	KeyBindings struct {
-		Quit        string `json:"quit"`
-		NextBox     string `json:"nextBox"`
-		PreviousBox string `json:"previousBox"`
-		SelectUp    string `json:"selectUp"`
-		SelectDown  string `json:"selectDown"`
-		Execute     string `json:"execute"`
-		Backspace   string `json:"backspace"`
-		Rename      string `json:"rename"`
-		Move        string `json:"move"`
-		Delete      string `json:"delete"`
-		Copy        string `json:"copy"`
-	} `json:"keyBindings"`
+	KeyBindings map[string]string `json:"keyBindings"`
+	// Keymap is the preset the key bindings apply to, "default" or "vim"
+	Keymap string `json:"keymap"`
 	Theme  string `json:"theme"`
 	Themes struct {
 		Dark struct {
@@ -58,9 +50,48 @@ type Config struct {
 		Style string `json:"style"`
 	} `json:"font"`
 	FileFilters struct {
-		ShowHiddenFiles bool     `json:"showHiddenFiles"`
-		FileExtensions  []string `json:"fileExtensions"`
+		ShowHiddenFiles   bool     `json:"showHiddenFiles"`
+		HideGitIgnored    bool     `json:"hideGitIgnored"`
+		FileExtensions    []string `json:"fileExtensions"`
+		ExcludeExtensions []string `json:"excludeExtensions"`
 	} `json:"fileFilters"`
+	LSColors struct {
+		Enabled bool              `json:"enabled"`
+		Colors  map[string]string `json:"colors"`
+	} `json:"lsColors"`
+	Sort struct {
+		By      string `json:"by"`
+		Reverse bool   `json:"reverse"`
+	} `json:"sort"`
+	Frecency struct {
+		Enabled bool    `json:"enabled"`
+		MaxAge  float64 `json:"maxAge"`
+	} `json:"frecency"`
+	Layout   string                `json:"layout"`
+	Layouts  map[string]LayoutNode `json:"layouts"`
+	TreeView struct {
+		Enabled bool `json:"enabled"`
+	} `json:"treeView"`
+	Mouse struct {
+		Enabled bool `json:"enabled"`
+	} `json:"mouse"`
+	Clipboard struct {
+		Shared bool `json:"shared"`
+	} `json:"clipboard"`
+	// Confirm turns off the confirmations asked before deleting, overwriting
+	// and discarding, which are on when left out of the config
+	Confirm struct {
+		SkipDelete    bool `json:"skipDelete"`
+		SkipOverwrite bool `json:"skipOverwrite"`
+		SkipDiscard   bool `json:"skipDiscard"`
+		// TypeNameForRecursiveDelete asks for the name to be typed before
+		// deleting a directory with everything inside it
+		TypeNameForRecursiveDelete bool `json:"typeNameForRecursiveDelete"`
+	} `json:"confirm"`
+	ListView struct {
+		Enabled bool     `json:"enabled"`
+		Columns []string `json:"columns"`
+	} `json:"listView"`
 	Notifications struct {
 		Enabled  bool `json:"enabled"`
 		Duration int  `json:"duration"`
@@ -77,22 +108,44 @@ type Config struct {
 	PreferredEditor string `json:"preferredEditor"`
 }
 
+// LayoutNode describes a layout as a tree of splits. A node either names a
+// box, one of "directories", "files", "search", "info", "parent" or
+// "preview", or splits its area into "rows" or "columns" between its
+// children. Children share the space by Weight, except those with a fixed
+// Size, and never get less than MinSize cells when it can be helped.
+type LayoutNode struct {
+	Box      string       `json:"box,omitempty"`
+	Split    string       `json:"split,omitempty"`
+	Weight   float64      `json:"weight,omitempty"`
+	Size     int          `json:"size,omitempty"`
+	MinSize  int          `json:"minSize,omitempty"`
+	Children []LayoutNode `json:"children,omitempty"`
+}
+
 type FileInfo struct {
-	Name           string
-	Permissions    string
-	Owner          string
-	IsExecutable   bool
-	IsSymlink      bool
-	SymlinkTarget  string
-	MountPoint     string
-	SELinuxContext string
-	GitRepoStatus  string
-	LastAccessTime string
-	CreationTime   string
-	Size           int64
-	FileType       string
-	Inode          uint64
-	HardLinksCount uint64
+	Name            string `json:"name"`
+	Path            string `json:"path"`
+	Permissions     string `json:"permissions"`
+	Owner           string `json:"owner"`
+	IsExecutable    bool   `json:"isExecutable"`
+	IsSymlink       bool   `json:"isSymlink"`
+	SymlinkTarget   string `json:"symlinkTarget"`
+	IsBrokenSymlink bool   `json:"isBrokenSymlink"`
+	MountPoint      string `json:"mountPoint"`
+	SELinuxContext  string `json:"selinuxContext"`
+	GitRepoStatus   string `json:"gitRepoStatus"`
+	// LastAccessTime and CreationTime are the relative times shown in the
+	// interface, AccessTime and CreateTime the times themselves.
+	LastAccessTime string    `json:"-"`
+	CreationTime   string    `json:"-"`
+	AccessTime     time.Time `json:"lastAccessTime"`
+	CreateTime     time.Time `json:"creationTime"`
+	ModTime        time.Time `json:"modTime"`
+	Size           int64     `json:"size"`
+	FileType       string    `json:"fileType"`
+	Inode          uint64    `json:"inode"`
+	HardLinksCount uint64    `json:"hardLinksCount"`
+	IsGitIgnored   bool      `json:"isGitIgnored"`
 }
 
 func ConfigLocations() []string {
@@ -156,6 +209,27 @@ func FindConfigFile() (string, error) {
 	}
 }
 
+// DataFile returns the absolute path of a data file such as bookmarks.json.
+// Data files live next to the config file in use, or in the first per-user
+// config location when no config file was found.
+func DataFile(name string) string {
+	dir := "."
+	if configPath, err := FindConfigFile(); err == nil {
+		dir = filepath.Dir(configPath)
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		for _, path := range ConfigLocations() {
+			if strings.HasPrefix(path, homeDir) {
+				dir = filepath.Dir(path)
+				break
+			}
+		}
+	}
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	return filepath.Join(dir, name)
+}
+
 func expandPath(path string) (string, error) {
 	if len(path) == 0 || path[0] != '~' {
 		return path, nil
@@ -0,0 +1,536 @@
+package events
+
+import (
+	"fmt"
+	"lds/config"
+	"lds/fileops"
+	"lds/ui"
+	"lds/utils"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is a named command. Name is also its entry in the keyBindings
+// section of the config, so the command palette can show the key it is bound
+// to.
+type Action struct {
+	Name     string
+	Category string
+	Title    string
+	Run      func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo)
+}
+
+// actions lists every action in the order the command palette shows them. It
+// is filled in by init since the palette is an action itself.
+var actions []Action
+
+func init() {
+	actions = []Action{
+		{"quit", "General", "Quit", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.Quit = true
+		}},
+		{"commandPalette", "General", "Show all commands", commandPalette},
+		{"help", "General", "Show the key bindings", showHelp},
+		{"notifications", "General", "Show the notifications history", showNotifications},
+		{"dismiss", "General", "Dismiss the notifications, errors included", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.Notifications.Dismiss()
+		}},
+
+		{"nextBox", "Navigation", "Focus the next box", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			focusNextBox(state, 1)
+		}},
+		{"previousBox", "Navigation", "Focus the previous box", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			focusNextBox(state, -1)
+		}},
+		{"selectUp", "Navigation", "Select the previous entry", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			selectUp(state)
+		}},
+		{"selectDown", "Navigation", "Select the next entry", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			selectDown(state, boxes)
+		}},
+		{"selectFirst", "Navigation", "Select the first entry, or the one given by a count", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			selectEntry(state, state.CurrentBox, max(state.count-1, 0))
+		}},
+		{"selectLast", "Navigation", "Select the last entry, or the one given by a count", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			if state.count > 0 {
+				selectEntry(state, state.CurrentBox, state.count-1)
+			} else if state.CurrentBox < len(boxes) {
+				selectEntry(state, state.CurrentBox, len(boxes[state.CurrentBox])-1)
+			}
+		}},
+		{"collapse", "Navigation", "Collapse the directory in tree view, or go to the parent directory", func(_ tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			if state.CurrentBox == 0 && cfg.TreeView.Enabled && !state.Commander {
+				collapseOrLeave(state, boxes[0])
+			} else {
+				state.ChangeDirectory("", true)
+			}
+		}},
+		{"expand", "Navigation", "Expand the directory in tree view, or enter it", func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			if state.Commander {
+				enterPaneEntry(screen, cfg, state, boxes[0], false)
+			} else if state.CurrentBox == 0 && cfg.TreeView.Enabled {
+				expandOrEnter(state, boxes[0])
+			} else if state.CurrentBox == 0 && len(boxes[0]) > 0 {
+				state.ChangeDirectory(boxes[0][state.SelectedIndices[0]].Path, false)
+			}
+		}},
+		{"execute", "Navigation", "Open the selected directory or file", openSelection},
+		{"goBack", "Navigation", "Go to the parent directory", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.ChangeDirectory("", true)
+		}},
+		{"historyBack", "Navigation", "Go back in history", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.GoBack()
+		}},
+		{"historyForward", "Navigation", "Go forward in history", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.GoForward()
+		}},
+		{"history", "Navigation", "Recent directories", func(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			if dir, ok := SelectFromList(screen, cfg, "Recent directories", state.recentCandidates); ok {
+				state.ChangeDirectory(dir, false)
+			}
+		}},
+		{"jump", "Navigation", "Jump to a frequently used directory", func(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			jumpByFrecency(screen, cfg, state)
+		}},
+		{"goToPath", "Navigation", "Go to a path", func(screen tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			goToPath(screen, state)
+		}},
+
+		{"focusSearch", "Search", "Move to the Search box", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.searchFrom = state.CurrentBox
+			state.CurrentBox = 2
+		}},
+		{"leaveSearch", "Search", "Go back from the Search box to the list", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.CurrentBox = state.searchFrom
+		}},
+		{"backspace", "Search", "Delete the last character of the search", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			if len(state.UserInput) > 0 {
+				state.UserInput = state.UserInput[:len(state.UserInput)-1]
+			}
+		}},
+		{"nextMatch", "Search", "Select the next entry matching the search", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			selectMatch(state, boxes, 1)
+		}},
+		{"previousMatch", "Search", "Select the previous entry matching the search", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			selectMatch(state, boxes, -1)
+		}},
+		{"commandLine", "General", "Enter a command such as :cd or :sort", commandLine},
+
+		{"jumpToBookmark", "Bookmarks", "Jump to a bookmark", func(screen tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			jumpToBookmarkKey(screen, state)
+		}},
+		{"bookmarks", "Bookmarks", "Manage bookmarks", func(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			manageBookmarks(screen, cfg, state)
+		}},
+
+		{"mark", "Files", "Mark or unmark the selected entry", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			if file := selectedEntry(state, boxes); file != nil {
+				state.toggleMark(*file)
+			}
+		}},
+		{"yank", "Files", "Yank the marked or selected entries", func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			state.yank(screen, cfg, boxes, false)
+		}},
+		{"cut", "Files", "Cut the marked or selected entries", func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			state.yank(screen, cfg, boxes, true)
+		}},
+		{"paste", "Files", "Paste the yanked or cut entries here", paste},
+		{"clearClipboard", "Files", "Empty the clipboard", clearClipboard},
+		{"copyName", "Files", "Copy the name of the selection to the system clipboard", func(screen tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			copyToSystem(screen, state, boxes, filepath.Base)
+		}},
+		{"copyPath", "Files", "Copy the absolute path of the selection to the system clipboard", func(screen tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			copyToSystem(screen, state, boxes, func(path string) string { return path })
+		}},
+		{"copyRelativePath", "Files", "Copy the path of the selection relative to where lds started", func(screen tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			copyToSystem(screen, state, boxes, state.relativePath)
+		}},
+		{"copyParentDir", "Files", "Copy the directory of the selection to the system clipboard", func(screen tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			copyToSystem(screen, state, boxes, filepath.Dir)
+		}},
+		{"rename", "Files", "Rename the selected file", renameSelected},
+		{"move", "Files", "Move the selected file", moveSelected},
+		{"delete", "Files", "Delete the selected file or directory", deleteSelected},
+		{"copy", "Files", "Copy the selected file", copySelected},
+
+		{"toggleHidden", "View", "Show or hide hidden files", func(_ tcell.Screen, cfg *config.Config, _ *State, _ [][]config.FileInfo) {
+			cfg.FileFilters.ShowHiddenFiles = !cfg.FileFilters.ShowHiddenFiles
+		}},
+		{"toggleGitIgnored", "Git", "Show or hide files ignored by git", func(_ tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			cfg.FileFilters.HideGitIgnored = !cfg.FileFilters.HideGitIgnored
+			state.Reload = true
+		}},
+		{"toggleListView", "View", "Switch between names and the list view", func(_ tcell.Screen, cfg *config.Config, _ *State, _ [][]config.FileInfo) {
+			cfg.ListView.Enabled = !cfg.ListView.Enabled
+		}},
+		{"toggleTreeView", "View", "Switch the Directories box to a tree", func(_ tcell.Screen, cfg *config.Config, _ *State, _ [][]config.FileInfo) {
+			cfg.TreeView.Enabled = !cfg.TreeView.Enabled
+		}},
+		{"cycleLayout", "View", "Switch to the next layout", func(_ tcell.Screen, cfg *config.Config, _ *State, _ [][]config.FileInfo) {
+			cfg.Layout = ui.NextLayout(cfg, cfg.Layout)
+		}},
+
+		{"previewUp", "View", "Scroll the preview up", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.PreviewScroll = max(state.PreviewScroll-previewPage(state), 0)
+		}},
+		{"previewDown", "View", "Scroll the preview down", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.PreviewScroll += previewPage(state)
+		}},
+
+		{"growWidth", "View", "Make the focused box wider", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			resizeFocusedBox(state, "columns", true)
+		}},
+		{"shrinkWidth", "View", "Make the focused box narrower", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			resizeFocusedBox(state, "columns", false)
+		}},
+		{"growHeight", "View", "Make the focused box taller", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			resizeFocusedBox(state, "rows", true)
+		}},
+		{"shrinkHeight", "View", "Make the focused box shorter", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			resizeFocusedBox(state, "rows", false)
+		}},
+
+		{"cycleSort", "Sort", "Switch to the next sort order", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.SortBy = utils.NextSortMode(state.SortBy)
+		}},
+		sortAction("sortByName", "name"),
+		sortAction("sortBySize", "size"),
+		sortAction("sortByTime", "time"),
+		sortAction("sortByExtension", "extension"),
+		{"reverseSort", "Sort", "Reverse the sort order", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.SortReverse = !state.SortReverse
+		}},
+
+		{"newTab", "Tabs", "Open a new tab", func(_ tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			state.NewTab(cfg)
+		}},
+		{"closeTab", "Tabs", "Close the tab", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.CloseTab()
+		}},
+		{"nextTab", "Tabs", "Switch to the next tab", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.SwitchTab(state.ActiveTab + 1)
+		}},
+		{"previousTab", "Tabs", "Switch to the previous tab", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.SwitchTab(state.ActiveTab - 1)
+		}},
+		{"commander", "Tabs", "Switch commander mode on or off", func(_ tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			state.ToggleCommander(cfg)
+		}},
+
+		{"leaveCommander", "Commander", "Go back to the normal view", func(_ tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+			state.ToggleCommander(cfg)
+		}},
+		{"switchPane", "Commander", "Switch to the other pane", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.SwitchTab(state.otherTab())
+		}},
+		{"pageUp", "Commander", "Select the entry a page up", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			selectPage(state, boxes[0], -1)
+		}},
+		{"pageDown", "Commander", "Select the entry a page down", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			selectPage(state, boxes[0], 1)
+		}},
+		{"paneParent", "Commander", "Go to the parent directory", func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			state.ChangeDirectory("", true)
+		}},
+		{"markNext", "Commander", "Mark or unmark the selected entry and select the next one", func(_ tcell.Screen, _ *config.Config, state *State, boxes [][]config.FileInfo) {
+			markEntry(state, boxes[0])
+			selectDown(state, boxes)
+		}},
+		{"copyToPane", "Commander", "Copy the marked or selected entries to the other pane", func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			transferSelection(screen, cfg, state, boxes[0], false)
+		}},
+		{"moveToPane", "Commander", "Move the marked or selected entries to the other pane", func(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+			transferSelection(screen, cfg, state, boxes[0], true)
+		}},
+	}
+	for n := 1; n <= 9; n++ {
+		actions = append(actions, Action{fmt.Sprintf("goToTab%d", n), "Tabs", fmt.Sprintf("Switch to tab %d", n), func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+			if n <= len(state.Tabs) {
+				state.SwitchTab(n - 1)
+			}
+		}})
+	}
+}
+
+func sortAction(name, by string) Action {
+	return Action{name, "Sort", "Sort by " + by, func(_ tcell.Screen, _ *config.Config, state *State, _ [][]config.FileInfo) {
+		state.SortBy = by
+	}}
+}
+
+// Actions returns every action lds supports.
+func Actions() []Action {
+	return actions
+}
+
+func findAction(name string) (Action, bool) {
+	for _, action := range actions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return Action{}, false
+}
+
+// commandPalette lists every action with the key it is bound to, narrowed
+// down by fuzzy matching what is typed, and runs the one chosen.
+func commandPalette(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	keymap := state.keymap(cfg)
+	labels := make(map[string]Action)
+	var all []string
+	for _, action := range actions {
+		if action.Name == "commandPalette" {
+			continue
+		}
+		label := fmt.Sprintf("%-48s %s", action.Category+": "+action.Title, keymap.KeysFor(action.Name))
+		labels[label] = action
+		all = append(all, label)
+	}
+	candidates := func(query string) []string {
+		if query == "" {
+			return all
+		}
+		scores := make(map[string]int)
+		var matches []string
+		for _, label := range all {
+			action := labels[label]
+			if score, ok := utils.FuzzyMatch(query, action.Category+": "+action.Title+" "+action.Name); ok {
+				scores[label] = score
+				matches = append(matches, label)
+			}
+		}
+		slices.SortStableFunc(matches, func(a, b string) int { return scores[b] - scores[a] })
+		return matches
+	}
+
+	label, ok := SelectFromList(screen, cfg, "Commands", candidates)
+	if !ok {
+		return
+	}
+	labels[label].Run(screen, cfg, state, boxes)
+}
+
+// previewPage returns how many lines the preview scrolls by at a time, a page
+// less one line to keep some context.
+func previewPage(state *State) int {
+	preview := state.Layout.Preview
+	if preview.Empty() {
+		// Without a preview column the file is shown in the Directories box
+		preview = state.Layout.Boxes[0]
+	}
+	return max(preview.Height-3, 1)
+}
+
+// focusNextBox moves the focus by step boxes, skipping the ones the layout
+// leaves out.
+func focusNextBox(state *State, step int) {
+	count := len(ui.Titles)
+	for i := 1; i <= count; i++ {
+		next := ((state.CurrentBox+i*step)%count + count) % count
+		if !state.Layout.Boxes[next].Empty() {
+			state.CurrentBox = next
+			return
+		}
+	}
+}
+
+func selectUp(state *State) {
+	currentBox := state.CurrentBox
+	if currentBox < len(state.SelectedIndices) && state.SelectedIndices[currentBox] > 0 {
+		state.SelectedIndices[currentBox]--
+		if state.SelectedIndices[currentBox] < state.ScrollPositions[currentBox] {
+			state.ScrollPositions[currentBox]--
+		}
+	}
+}
+
+func selectDown(state *State, boxes [][]config.FileInfo) {
+	currentBox := state.CurrentBox
+	maxHeight := state.Layout.Boxes[currentBox].Height
+	if currentBox < len(boxes) && state.SelectedIndices[currentBox] < len(boxes[currentBox])-1 {
+		state.SelectedIndices[currentBox]++
+		if state.SelectedIndices[currentBox] >= state.ScrollPositions[currentBox]+maxHeight-3 {
+			state.ScrollPositions[currentBox]++
+		}
+	}
+}
+
+// selectEntry selects the entry at index in the Directories or Files box and
+// scrolls it into view.
+func selectEntry(state *State, box, index int) {
+	if box > 1 {
+		return
+	}
+	maxLines := state.Layout.Boxes[box].Height - 3
+	state.SelectedIndices[box] = max(index, 0)
+	if index < state.ScrollPositions[box] {
+		state.ScrollPositions[box] = max(index, 0)
+	} else if index >= state.ScrollPositions[box]+maxLines {
+		state.ScrollPositions[box] = index - maxLines + 1
+	}
+}
+
+// selectMatch selects the next entry of the focused list, searching in
+// direction step and wrapping around, whose name contains the search query.
+func selectMatch(state *State, boxes [][]config.FileInfo, step int) {
+	box := state.CurrentBox
+	query := strings.ToLower(string(state.UserInput))
+	if box > 1 || query == "" || len(boxes[box]) == 0 {
+		return
+	}
+	entries := boxes[box]
+	for i := 1; i <= len(entries); i++ {
+		index := ((state.SelectedIndices[box]+i*step)%len(entries) + len(entries)) % len(entries)
+		if strings.Contains(strings.ToLower(entries[index].Name), query) {
+			selectEntry(state, box, index)
+			return
+		}
+	}
+}
+
+// selectedEntry returns the entry selected in the focused list, or nil.
+func selectedEntry(state *State, boxes [][]config.FileInfo) *config.FileInfo {
+	box := state.CurrentBox
+	if box > 1 || len(boxes[box]) == 0 {
+		return nil
+	}
+	return &boxes[box][state.SelectedIndices[box]]
+}
+
+// selectedFile returns the entry selected in the Files box, which the file
+// operations work on, or nil when the Files box is not focused.
+func selectedFile(state *State, boxes [][]config.FileInfo) *config.FileInfo {
+	if state.CurrentBox != 1 || len(boxes[1]) == 0 {
+		return nil
+	}
+	return &boxes[1][state.SelectedIndices[1]]
+}
+
+func renameSelected(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	file := selectedFile(state, boxes)
+	if file == nil {
+		return
+	}
+	newName := PromptForInput(screen, "Rename to:")
+	if newName != "" && confirmOverwrite(screen, cfg, file.Path, newName) {
+		err := fileops.RenameFile(file.Path, newName)
+		if err != nil {
+			state.notifyError("Error renaming %s: %v", file.Name, err)
+		} else {
+			state.notifySuccess("Renamed %s to %s", file.Name, newName)
+			state.Reload = true
+		}
+	}
+}
+
+func moveSelected(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	file := selectedFile(state, boxes)
+	if file == nil {
+		return
+	}
+	newLocation := promptForTarget(screen, state, "Move to")
+	if newLocation != "" && confirmOverwrite(screen, cfg, file.Path, fileops.TargetPath(file.Path, newLocation)) {
+		err := fileops.MoveFile(file.Path, newLocation)
+		if err != nil {
+			state.notifyError("Error moving %s: %v", file.Name, err)
+		} else {
+			state.notifySuccess("Moved %s to %s", file.Name, newLocation)
+			state.Reload = true
+		}
+	}
+}
+
+// deleteSelected deletes the selected file, or the selected directory with
+// everything inside it, once that has been confirmed.
+func deleteSelected(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	file := selectedEntry(state, boxes)
+	if file == nil {
+		return
+	}
+	path, err := filepath.Abs(file.Path)
+	if err != nil {
+		path = file.Path
+	}
+	isDir := file.FileType == "Directory"
+	message := fmt.Sprintf("Delete %s?", file.Name)
+	if isDir {
+		message += " It is deleted with everything inside it."
+	}
+	switch {
+	case isDir && cfg.Confirm.TypeNameForRecursiveDelete:
+		if !confirmByTyping(screen, cfg, "Delete", message, []string{path}, file.Name) {
+			return
+		}
+	case !cfg.Confirm.SkipDelete:
+		if !confirm(screen, cfg, "Delete", message, []string{path}) {
+			return
+		}
+	}
+
+	if isDir {
+		err = fileops.DeleteTree(file.Path)
+	} else {
+		err = fileops.DeleteFile(file.Path)
+	}
+	if err != nil {
+		state.notifyError("Error deleting %s: %v", file.Name, err)
+	} else {
+		state.notifySuccess("Deleted %s", file.Name)
+		delete(state.Marked, path)
+		state.Reload = true
+	}
+}
+
+// confirmOverwrite asks before an operation on src replaces target, unless
+// target does not exist or the confirmation is turned off. It reports
+// whether to go ahead.
+func confirmOverwrite(screen tcell.Screen, cfg *config.Config, src, target string) bool {
+	if cfg.Confirm.SkipOverwrite {
+		return true
+	}
+	targetInfo, err := os.Lstat(target)
+	if err != nil {
+		return true
+	}
+	// Renaming a file to itself, say to change the case of its name, is fine
+	if srcInfo, err := os.Lstat(src); err == nil && os.SameFile(srcInfo, targetInfo) {
+		return true
+	}
+	if path, err := filepath.Abs(target); err == nil {
+		target = path
+	}
+	return confirm(screen, cfg, "Overwrite", fmt.Sprintf("%s exists already. Replace it?", filepath.Base(target)), []string{target})
+}
+
+func copySelected(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	file := selectedFile(state, boxes)
+	if file == nil {
+		return
+	}
+	newLocation := promptForTarget(screen, state, "Copy to")
+	if newLocation != "" && confirmOverwrite(screen, cfg, file.Path, fileops.TargetPath(file.Path, newLocation)) {
+		err := fileops.CopyFile(file.Path, newLocation)
+		if err != nil {
+			state.notifyError("Error copying %s: %v", file.Name, err)
+		} else {
+			state.notifySuccess("Copied %s to %s", file.Name, newLocation)
+			state.Reload = true
+		}
+	}
+}
+
+// runAction runs the named action. It is how the key handler reaches the
+// actions, so keys and the command palette behave the same.
+func runAction(name string, screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	action, ok := findAction(name)
+	if !ok {
+		log.Printf("Unknown action %q", name)
+		return
+	}
+	action.Run(screen, cfg, state, boxes)
+}
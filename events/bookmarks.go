@@ -0,0 +1,112 @@
+package events
+
+import (
+	"fmt"
+	"os"
+
+	"lds/config"
+	"lds/ui"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// jumpToBookmarkKey waits for one more key press and jumps to the bookmark
+// named by that character.
+func jumpToBookmarkKey(screen tcell.Screen, state *State) {
+	for {
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventKey:
+			if ev.Key() != tcell.KeyRune {
+				return
+			}
+			dir, ok := state.Bookmarks.Get(string(ev.Rune()))
+			if !ok {
+				state.notifyWarning("No bookmark bound to %q", ev.Rune())
+				return
+			}
+			state.ChangeDirectory(dir, false)
+			return
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
+
+// manageBookmarks shows the bookmarks overlay, where bookmarks can be added for
+// the current directory, renamed, removed and jumped to.
+func manageBookmarks(screen tcell.Screen, cfg *config.Config, state *State) {
+	style, borderStyle := ui.PopupStyles(cfg)
+	selected := 0
+	for {
+		names := state.Bookmarks.Names()
+		nameWidth := 0
+		for _, name := range names {
+			nameWidth = max(nameWidth, len([]rune(name)))
+		}
+		items := make([]string, len(names))
+		for i, name := range names {
+			dir, _ := state.Bookmarks.Get(name)
+			items[i] = fmt.Sprintf("%-*s  %s", nameWidth, name, dir)
+		}
+		selected = min(selected, max(len(items)-1, 0))
+
+		ui.DrawListPopup(screen, "Bookmarks", "a: add current directory  r: rename  d: remove  Enter: jump  Esc: close", items, selected, style, borderStyle)
+		screen.Show()
+
+		ev := screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEscape, tcell.KeyCtrlC:
+				return
+			case tcell.KeyUp:
+				if selected > 0 {
+					selected--
+				}
+			case tcell.KeyDown:
+				selected++
+			case tcell.KeyEnter:
+				if len(names) > 0 {
+					dir, _ := state.Bookmarks.Get(names[selected])
+					state.ChangeDirectory(dir, false)
+					return
+				}
+			case tcell.KeyRune:
+				switch ev.Rune() {
+				case 'a':
+					name := PromptForInput(screen, "Bookmark name (a single character can be jumped to with Alt+b):")
+					if name == "" {
+						break
+					}
+					cwd, err := os.Getwd()
+					if err == nil {
+						err = state.Bookmarks.Set(name, cwd)
+					}
+					if err != nil {
+						state.notifyError("Error adding bookmark: %v", err)
+					}
+				case 'r':
+					if len(names) == 0 {
+						break
+					}
+					newName := PromptForInput(screen, "Rename bookmark to:")
+					if newName == "" {
+						break
+					}
+					if err := state.Bookmarks.Rename(names[selected], newName); err != nil {
+						state.notifyError("Error renaming bookmark: %v", err)
+					}
+				case 'd':
+					if len(names) == 0 {
+						break
+					}
+					if err := state.Bookmarks.Remove(names[selected]); err != nil {
+						state.notifyError("Error removing bookmark: %v", err)
+					}
+				}
+			}
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
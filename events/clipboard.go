@@ -0,0 +1,132 @@
+package events
+
+import (
+	"io"
+	"lds/clipboard"
+	"lds/config"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// markedOrSelected returns the absolute paths of the marked entries, sorted,
+// or of the selected one when none are marked.
+func (s *State) markedOrSelected(boxes [][]config.FileInfo) []string {
+	var paths []string
+	for path := range s.Marked {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	if len(paths) == 0 {
+		file := selectedEntry(s, boxes)
+		if file == nil {
+			return nil
+		}
+		path, err := filepath.Abs(file.Path)
+		if err != nil {
+			s.notifyError("Error getting path: %v", err)
+			return nil
+		}
+		paths = []string{path}
+	}
+	return paths
+}
+
+// yank puts the marked entries, or the selected one when none are marked,
+// into the clipboard. cut makes pasting move them instead of copying.
+func (s *State) yank(screen tcell.Screen, cfg *config.Config, boxes [][]config.FileInfo, cut bool) {
+	paths := s.markedOrSelected(boxes)
+	if len(paths) == 0 || !confirmDiscard(screen, cfg, s) {
+		return
+	}
+	if err := s.Clipboard.Set(paths, cut); err != nil {
+		s.notifyError("Error saving the clipboard: %v", err)
+	}
+	clear(s.Marked)
+	if cut {
+		s.notify("Cut %d entries", len(paths))
+	} else {
+		s.notify("Yanked %d entries", len(paths))
+	}
+}
+
+// paste copies the entries in the clipboard into the current directory, or
+// moves them when they were cut, after which the clipboard is emptied.
+func paste(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+	if err := state.Clipboard.Sync(); err != nil {
+		state.notifyError("Error reading the clipboard: %v", err)
+	}
+	if state.Clipboard.Empty() {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		state.notifyError("Error getting current directory: %v", err)
+		return
+	}
+	// Entries that were cut are gone once moved, so only a copy can be pasted again
+	if transferFiles(screen, cfg, state, state.Clipboard.Paths, cwd, state.Clipboard.Cut) && state.Clipboard.Cut {
+		if err := state.Clipboard.Clear(); err != nil {
+			state.notifyError("Error saving the clipboard: %v", err)
+		}
+	}
+}
+
+func clearClipboard(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+	if !confirmDiscard(screen, cfg, state) {
+		return
+	}
+	if err := state.Clipboard.Clear(); err != nil {
+		state.notifyError("Error saving the clipboard: %v", err)
+	}
+}
+
+// confirmDiscard asks before entries that were cut and not pasted yet are
+// dropped from the clipboard, unless that confirmation is turned off. It
+// reports whether to go ahead.
+func confirmDiscard(screen tcell.Screen, cfg *config.Config, state *State) bool {
+	if err := state.Clipboard.Sync(); err != nil {
+		state.notifyError("Error reading the clipboard: %v", err)
+	}
+	if cfg.Confirm.SkipDiscard || !state.Clipboard.Cut || state.Clipboard.Empty() {
+		return true
+	}
+	return confirm(screen, cfg, "Discard", "The entries that were cut have not been pasted yet. Discard them?", state.Clipboard.Paths)
+}
+
+// copyToSystem puts format of the absolute path of the selection on the
+// system clipboard. In the Search box the selection is the best match.
+func copyToSystem(screen tcell.Screen, state *State, boxes [][]config.FileInfo, format func(path string) string) {
+	file := selectedEntry(state, boxes)
+	if file == nil && state.CurrentBox == 2 {
+		file = state.BestMatch
+	}
+	if file == nil {
+		return
+	}
+	path, err := filepath.Abs(file.Path)
+	if err != nil {
+		state.notifyError("Error getting path: %v", err)
+		return
+	}
+	text := format(path)
+	var tty io.Writer
+	if t, ok := screen.Tty(); ok {
+		tty = t
+	}
+	if err := clipboard.Copy(tty, text); err != nil {
+		state.notifyError("Error copying to the clipboard: %v", err)
+		return
+	}
+	state.notifySuccess("Copied %s to the clipboard", text)
+}
+
+// relativePath returns path relative to the directory lds started in.
+func (s *State) relativePath(path string) string {
+	if rel, err := filepath.Rel(s.startDir, path); err == nil {
+		return rel
+	}
+	return path
+}
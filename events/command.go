@@ -0,0 +1,85 @@
+package events
+
+import (
+	"lds/config"
+	"lds/utils"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// commandLine prompts for a command, as typed after ":" in vim, and runs it.
+func commandLine(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	if input := PromptWithCompletion(screen, ":", completeCommand, nil); input != "" {
+		runCommand(screen, cfg, state, boxes, input)
+	}
+}
+
+// runCommand runs a command line. Besides the commands below it accepts the
+// name of any action.
+//
+//	q, quit    quit lds
+//	cd PATH    go to PATH, like the go to path prompt
+//	sort MODE  sort by name, size, time or extension
+func runCommand(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo, input string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(input), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "q", "quit":
+		state.Quit = true
+	case "cd":
+		if arg == "" {
+			arg = "~"
+		}
+		state.goTo(arg)
+	case "sort":
+		if !slices.Contains(utils.SortModes, arg) {
+			state.notifyError("Unknown sort mode %q", arg)
+			return
+		}
+		state.SortBy = arg
+	default:
+		if _, ok := findAction(name); !ok {
+			state.notifyError("Unknown command %q", name)
+			return
+		}
+		runAction(name, screen, cfg, state, boxes)
+	}
+}
+
+// completeCommand completes the command names, the paths after cd and the
+// modes after sort.
+func completeCommand(input string) []string {
+	if path, ok := strings.CutPrefix(input, "cd "); ok {
+		var candidates []string
+		for _, candidate := range utils.CompletePath(path) {
+			candidates = append(candidates, "cd "+candidate)
+		}
+		return candidates
+	}
+	if mode, ok := strings.CutPrefix(input, "sort "); ok {
+		var candidates []string
+		for _, candidate := range utils.SortModes {
+			if strings.HasPrefix(candidate, mode) {
+				candidates = append(candidates, "sort "+candidate)
+			}
+		}
+		return candidates
+	}
+	if input == "" {
+		return nil
+	}
+	var candidates []string
+	for _, name := range []string{"cd ", "quit", "sort "} {
+		if strings.HasPrefix(name, input) {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, action := range actions {
+		if strings.HasPrefix(action.Name, input) {
+			candidates = append(candidates, action.Name)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,222 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"lds/config"
+	"lds/fileops"
+	"lds/ui"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ToggleCommander switches the two pane commander mode on or off. The panes
+// show the active tab and the other tab, so a second tab is opened in the
+// current directory when there is only one.
+func (s *State) ToggleCommander(cfg *config.Config) {
+	s.Commander = !s.Commander
+	if s.Commander && len(s.Tabs) < 2 {
+		s.NewTab(cfg)
+	}
+	s.Reload = true
+}
+
+// CommanderPanes returns the tabs shown in the left and right pane.
+func (s *State) CommanderPanes() (left, right int) {
+	other := s.otherTab()
+	return min(s.ActiveTab, other), max(s.ActiveTab, other)
+}
+
+// otherTab returns the index of the tab that was active before the current
+// one, or the next tab when that is not known.
+func (s *State) otherTab() int {
+	other := s.previousTab
+	if other == s.ActiveTab || other >= len(s.Tabs) {
+		other = (s.ActiveTab + 1) % len(s.Tabs)
+	}
+	return other
+}
+
+// HandleCommanderInput handles an event in commander mode. entries is the
+// listing of the active pane, which keys move through like the Directories
+// box.
+func HandleCommanderInput(screen tcell.Screen, cfg *config.Config, state *State, entries []config.FileInfo) {
+	switch ev := screen.PollEvent().(type) {
+	case *tcell.EventKey:
+		// The actions that move through a list work on the pane as box 0
+		state.CurrentBox = 0
+		state.dispatchKey(screen, cfg, [][]config.FileInfo{entries, nil, nil}, ev)
+	case *tcell.EventMouse:
+		handleCommanderMouse(screen, cfg, state, entries, ev)
+	case *tcell.EventResize:
+		screen.Sync()
+	}
+}
+
+// enterPaneEntry changes into the directory selected in the active pane. A
+// selected file is opened in the editor when open is set.
+func enterPaneEntry(screen tcell.Screen, cfg *config.Config, state *State, entries []config.FileInfo, open bool) {
+	if state.SelectedIndices[0] >= len(entries) {
+		return
+	}
+	if entry := entries[state.SelectedIndices[0]]; entry.FileType == "Directory" {
+		state.ChangeDirectory(entry.Path, false)
+	} else if open {
+		openInEditor(screen, cfg, entry.Path)
+	}
+}
+
+// selectPage moves the selection of the active pane a page in direction step.
+func selectPage(state *State, entries []config.FileInfo, step int) {
+	page := max(state.Layout.Boxes[0].Height-2, 1)
+	selectEntry(state, 0, max(min(state.SelectedIndices[0]+step*page, len(entries)-1), 0))
+}
+
+// handleCommanderMouse selects the row that is clicked in the active pane and
+// opens it on a double-click. Clicking the other pane makes it the active one.
+func handleCommanderMouse(screen tcell.Screen, cfg *config.Config, state *State, entries []config.FileInfo, ev *tcell.EventMouse) {
+	x, y := ev.Position()
+	pane := state.Layout.Boxes[0]
+	pressed, double := state.mouse.press(ev)
+	switch {
+	case ev.Buttons()&(tcell.WheelUp|tcell.WheelDown) != 0 && pane.Contains(x, y):
+		delta := wheelStep
+		if ev.Buttons()&tcell.WheelUp != 0 {
+			delta = -wheelStep
+		}
+		rows := max(pane.Height-2, 1)
+		state.ScrollPositions[0] = max(min(state.ScrollPositions[0]+delta, len(entries)-rows), 0)
+		state.SelectedIndices[0] = max(min(state.SelectedIndices[0], state.ScrollPositions[0]+rows-1), state.ScrollPositions[0])
+	case !pressed:
+	case !pane.Contains(x, y):
+		_, height := screen.Size()
+		if y < height-1 {
+			state.SwitchTab(state.otherTab())
+		}
+	case y > pane.Y && y < pane.Y+pane.Height-1:
+		row := state.ScrollPositions[0] + y - pane.Y - 1
+		if row >= len(entries) {
+			return
+		}
+		state.SelectedIndices[0] = row
+		if !double {
+			return
+		}
+		if entry := entries[row]; entry.FileType == "Directory" {
+			state.ChangeDirectory(entry.Path, false)
+		} else {
+			openInEditor(screen, cfg, entry.Path)
+		}
+	}
+}
+
+func markEntry(state *State, entries []config.FileInfo) {
+	if state.SelectedIndices[0] < len(entries) {
+		state.toggleMark(entries[state.SelectedIndices[0]])
+	}
+}
+
+// conflictChoices are the answers to a file that is in the way of a transfer.
+var conflictChoices = []ui.Choice{
+	{Key: 'o', Label: "Overwrite"},
+	{Key: 's', Label: "Skip"},
+	{Key: 'k', Label: "Keep both"},
+	{Key: 'a', Label: "Overwrite all"},
+	{Key: 'l', Label: "Skip all"},
+}
+
+// transferSelection copies or moves the entries marked in the active pane, or
+// the selected entry when none are marked, into the other pane's directory.
+func transferSelection(screen tcell.Screen, cfg *config.Config, state *State, entries []config.FileInfo, move bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		state.notifyError("Error getting current directory: %v", err)
+		return
+	}
+	var sources []string
+	for _, entry := range entries {
+		if path, err := filepath.Abs(entry.Path); err == nil && state.Marked[path] {
+			sources = append(sources, path)
+		}
+	}
+	if len(sources) == 0 && state.SelectedIndices[0] < len(entries) {
+		sources = []string{filepath.Join(cwd, entries[state.SelectedIndices[0]].Path)}
+	}
+	if len(sources) == 0 {
+		return
+	}
+	transferFiles(screen, cfg, state, sources, state.Tabs[state.otherTab()].Dir, move)
+}
+
+// transferFiles copies or moves sources into target, showing the progress
+// and asking what to do about files that exist already, unless overwrite
+// confirmations are turned off. The sources are unmarked afterwards. It
+// reports whether the transfer ran to the end.
+func transferFiles(screen tcell.Screen, cfg *config.Config, state *State, sources []string, target string, move bool) bool {
+	title := "Copying"
+	if move {
+		title = "Moving"
+	}
+
+	var overwriteAll, skipAll bool
+	conflict := func(src, dst string) fileops.ConflictAction {
+		switch {
+		case overwriteAll, cfg.Confirm.SkipOverwrite:
+			return fileops.Overwrite
+		case skipAll:
+			return fileops.Skip
+		}
+		dialog := ui.Dialog{
+			Title:   "File exists",
+			Message: fmt.Sprintf("%s exists already in the target directory.", filepath.Base(dst)),
+			Paths:   []string{dst},
+			Choices: conflictChoices,
+		}
+		switch choose(screen, cfg, dialog, 1) {
+		case 'o':
+			return fileops.Overwrite
+		case 's':
+			return fileops.Skip
+		case 'k':
+			return fileops.KeepBoth
+		case 'a':
+			overwriteAll = true
+			return fileops.Overwrite
+		case 'l':
+			skipAll = true
+			return fileops.Skip
+		}
+		return fileops.Abort
+	}
+	style, borderStyle := ui.PopupStyles(cfg)
+	var lastDraw time.Time
+	progress := func(done, total int64, current string) {
+		if time.Since(lastDraw) < 50*time.Millisecond {
+			return
+		}
+		lastDraw = time.Now()
+		ui.DrawProgress(screen, title, filepath.Base(current), done, total, style, borderStyle)
+		screen.Show()
+	}
+
+	err := fileops.Transfer(sources, target, fileops.TransferOptions{Move: move, Conflict: conflict, Progress: progress})
+	switch {
+	case errors.Is(err, fileops.ErrAborted):
+		state.notifyWarning("%s stopped", title)
+	case err != nil:
+		state.notifyError("Error transferring files: %v", err)
+	default:
+		state.notifySuccess("%s %d entries to %s done", title, len(sources), target)
+	}
+	for path := range state.Marked {
+		if slices.Contains(sources, path) {
+			delete(state.Marked, path)
+		}
+	}
+	state.Reload = true
+	return err == nil
+}
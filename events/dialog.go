@@ -0,0 +1,76 @@
+package events
+
+import (
+	"lds/config"
+	"lds/ui"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// choose shows dialog until one of its choices is picked, with its key or
+// with Left/Right and Enter, starting from the choice at index selected. It
+// returns the key of the choice, or 0 when the dialog was cancelled with Esc.
+func choose(screen tcell.Screen, cfg *config.Config, dialog ui.Dialog, selected int) rune {
+	style, borderStyle := ui.PopupStyles(cfg)
+	for {
+		ui.DrawDialog(screen, dialog, selected, style, borderStyle)
+		screen.Show()
+
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEscape, tcell.KeyCtrlC:
+				return 0
+			case tcell.KeyEnter:
+				return dialog.Choices[selected].Key
+			case tcell.KeyLeft, tcell.KeyBacktab:
+				selected = (selected + len(dialog.Choices) - 1) % len(dialog.Choices)
+			case tcell.KeyRight, tcell.KeyTab:
+				selected = (selected + 1) % len(dialog.Choices)
+			case tcell.KeyRune:
+				for _, choice := range dialog.Choices {
+					if choice.Key == ev.Rune() {
+						return choice.Key
+					}
+				}
+			}
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
+
+// confirm asks a yes or no question about paths. No is selected to begin
+// with, so that a stray Enter does not confirm anything.
+func confirm(screen tcell.Screen, cfg *config.Config, title, message string, paths []string) bool {
+	return choose(screen, cfg, ui.Dialog{Title: title, Message: message, Paths: paths, Choices: ui.YesNo}, 1) == 'y'
+}
+
+// confirmByTyping asks for name to be typed before going ahead, for
+// operations that are hard to undo.
+func confirmByTyping(screen tcell.Screen, cfg *config.Config, title, message string, paths []string, name string) bool {
+	dialog := ui.Dialog{Title: title, Message: message, Paths: paths, Prompt: "Type " + name + " to confirm: "}
+	style, borderStyle := ui.PopupStyles(cfg)
+	for {
+		ui.DrawDialog(screen, dialog, 0, style, borderStyle)
+		screen.Show()
+
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEscape, tcell.KeyCtrlC:
+				return false
+			case tcell.KeyEnter:
+				return dialog.Input == name
+			case tcell.KeyBackspace, tcell.KeyBackspace2:
+				if input := []rune(dialog.Input); len(input) > 0 {
+					dialog.Input = string(input[:len(input)-1])
+				}
+			case tcell.KeyRune:
+				dialog.Input += string(ev.Rune())
+			}
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
@@ -2,9 +2,16 @@ package events
 
 import (
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
 
+	"lds/bookmarks"
+	"lds/clipboard"
 	"lds/config"
 	"lds/fileops"
+	"lds/frecency"
+	"lds/notifications"
 	"lds/ui"
 	"lds/utils"
 
@@ -12,7 +19,10 @@ import (
 	"github.com/gdamore/tcell/v2"
 )
 
-func WatchConfigFile(filename string, reloadConfig chan<- struct{}) {
+// WatchConfigFile signals reloadConfig whenever filename is written to, and
+// calls wake since the main loop is usually waiting for input. Changes that
+// come in before the last one was handled are merged into it.
+func WatchConfigFile(filename string, reloadConfig chan<- struct{}, wake func()) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		log.Fatal(err)
@@ -28,7 +38,11 @@ func WatchConfigFile(filename string, reloadConfig chan<- struct{}) {
 		select {
 		case event := <-watcher.Events:
 			if event.Op&fsnotify.Write == fsnotify.Write {
-				reloadConfig <- struct{}{}
+				select {
+				case reloadConfig <- struct{}{}:
+				default:
+				}
+				wake()
 			}
 		case err := <-watcher.Errors:
 			log.Println("error:", err)
@@ -37,147 +51,369 @@ func WatchConfigFile(filename string, reloadConfig chan<- struct{}) {
 }
 
 func PromptForInput(screen tcell.Screen, prompt string) string {
-	screen.Clear()
-	ui.DrawPrompt(screen, prompt)
-	screen.Show()
+	return PromptWithCompletion(screen, prompt, nil, nil)
+}
 
+// PromptWithCompletion works like PromptForInput but shows the candidates
+// returned by complete in a dropdown below the prompt. Tab inserts the
+// highlighted candidate, or the part all candidates have in common when none
+// is highlighted, and Up/Down move the highlight. Pressing one of the keys in
+// shortcuts replaces the input with its text. Esc cancels and returns "".
+func PromptWithCompletion(screen tcell.Screen, prompt string, complete func(input string) []string, shortcuts map[tcell.Key]string) string {
 	var input []rune
+	var candidates []string
+	selected := -1
 	for {
+		screen.Clear()
+		if complete != nil {
+			candidates = complete(string(input))
+			ui.DrawCompletions(screen, candidates, selected)
+		}
+		ui.DrawPrompt(screen, prompt+string(input))
+		screen.Show()
+
 		ev := screen.PollEvent()
 		switch ev := ev.(type) {
 		case *tcell.EventKey:
 			switch ev.Key() {
 			case tcell.KeyEnter:
 				return string(input)
+			case tcell.KeyEscape:
+				return ""
 			case tcell.KeyBackspace, tcell.KeyBackspace2:
 				if len(input) > 0 {
 					input = input[:len(input)-1]
 				}
+				selected = -1
+			case tcell.KeyTab:
+				if selected >= 0 && selected < len(candidates) {
+					input = []rune(candidates[selected])
+				} else if prefix := utils.CommonPrefix(candidates); len(prefix) > len(string(input)) {
+					input = []rune(prefix)
+				}
+				selected = -1
+			case tcell.KeyUp:
+				selected = max(selected-1, -1)
+			case tcell.KeyDown:
+				selected = min(selected+1, len(candidates)-1)
 			default:
-				if ev.Rune() != 0 {
+				if text, ok := shortcuts[ev.Key()]; ok {
+					input = []rune(text)
+				} else if ev.Rune() != 0 {
 					input = append(input, ev.Rune())
 				}
+				selected = -1
 			}
 		case *tcell.EventResize:
 			screen.Sync()
 		}
-		ui.DrawPrompt(screen, prompt+string(input))
-		screen.Show()
 	}
 }
 
-func HandleUserInput(screen tcell.Screen, cfg *config.Config, currentBox int, userInput []rune, boxes [][]config.FileInfo, selectedIndices []int, scrollPositions []int, bestMatch *config.FileInfo) (int, []rune, []int, []int, *config.FileInfo) {
-	ev := screen.PollEvent()
-	switch ev := ev.(type) {
-	case *tcell.EventKey:
-		switch ev.Key() {
-		case tcell.KeyCtrlC:
-			return -1, userInput, selectedIndices, scrollPositions, bestMatch
-		case tcell.KeyEscape:
-			if currentBox == 0 && len(boxes[currentBox]) > 0 { // Directory box
-				selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-				up := true
-				screen.Fini()
-				utils.ChangeDirectoryAndRerun(selectedFile.Name, up)
-			}
-		case tcell.KeyTab:
-			currentBox = (currentBox + 1) % len(ui.Titles)
-		case tcell.KeyUp:
-			if currentBox < len(selectedIndices) && selectedIndices[currentBox] > 0 {
-				selectedIndices[currentBox]--
-				if selectedIndices[currentBox] < scrollPositions[currentBox] {
-					scrollPositions[currentBox]--
-				}
-			}
-		case tcell.KeyDown:
-			// Define maxHeight based on the current box
-			var maxHeight int
-			switch currentBox {
-			case 0, 1:
-				maxHeight = ui.IncreasedBoxHeight
-			case 2, 3:
-				maxHeight = ui.HalfBoxHeight
-			}
-			if currentBox < len(selectedIndices) && selectedIndices[currentBox] < len(boxes[currentBox])-1 {
-				selectedIndices[currentBox]++
-				if selectedIndices[currentBox] >= scrollPositions[currentBox]+maxHeight-3 {
-					scrollPositions[currentBox]++
-				}
-			}
-		case tcell.KeyEnter:
-			if currentBox == 2 && bestMatch != nil { // Search box
-				fileops.OpenFileInEditor(cfg.PreferredEditor, bestMatch.Name)
-			} else if currentBox == 1 && len(boxes[currentBox]) > 0 { // File box
-				selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-				fileops.OpenFileInEditor(cfg.PreferredEditor, selectedFile.Name)
-			} else if currentBox == 0 && len(boxes[currentBox]) > 0 { // Directory box
-				selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-				up := false
-				screen.Fini()
-				utils.ChangeDirectoryAndRerun(selectedFile.Name, up)
-			}
-		case tcell.KeyBackspace, tcell.KeyBackspace2:
-			if currentBox == 2 && len(userInput) > 0 {
-				userInput = userInput[:len(userInput)-1]
-			}
-		case tcell.KeyRune:
-			if ev.Rune() == 'r' && ev.Modifiers() == (tcell.ModAlt) {
-				if currentBox == 1 && len(boxes[currentBox]) > 0 {
-					selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-					newName := PromptForInput(screen, "Rename to:")
-					if newName != "" {
-						err := fileops.RenameFile(selectedFile.Name, newName)
-						if err != nil {
-							log.Println("Error renaming file:", err)
-						} else {
-							log.Println("File renamed successfully")
-						}
-					}
-				}
-			} else if ev.Rune() == 'm' && ev.Modifiers() == (tcell.ModAlt) {
-				if currentBox == 1 && len(boxes[currentBox]) > 0 {
-					selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-					newLocation := PromptForInput(screen, "Move to:")
-					if newLocation != "" {
-						err := fileops.MoveFile(selectedFile.Name, newLocation)
-						if err != nil {
-							log.Println("Error moving file:", err)
-						} else {
-							log.Println("File moved successfully")
-						}
-					}
-				}
-			} else if ev.Rune() == 'd' && ev.Modifiers() == (tcell.ModAlt) {
-				if currentBox == 1 && len(boxes[currentBox]) > 0 {
-					selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-					err := fileops.DeleteFile(selectedFile.Name)
-					if err != nil {
-						log.Println("Error deleting file:", err)
-					} else {
-						log.Println("File deleted successfully")
-					}
+// SelectFromList shows a popup with the candidates for what has been typed so
+// far and returns the one chosen with Enter. ok is false when the popup was
+// closed with Esc or nothing matched.
+func SelectFromList(screen tcell.Screen, cfg *config.Config, title string, candidates func(query string) []string) (string, bool) {
+	style, borderStyle := ui.PopupStyles(cfg)
+	var input []rune
+	selected := 0
+	for {
+		items := candidates(string(input))
+		selected = min(selected, max(len(items)-1, 0))
+		ui.DrawListPopup(screen, title, "> "+string(input)+"_", items, selected, style, borderStyle)
+		screen.Show()
+
+		ev := screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEscape, tcell.KeyCtrlC:
+				return "", false
+			case tcell.KeyEnter:
+				if len(items) == 0 {
+					return "", false
 				}
-			} else if ev.Rune() == 'c' && ev.Modifiers() == (tcell.ModAlt) {
-				if currentBox == 1 && len(boxes[currentBox]) > 0 {
-					selectedFile := boxes[currentBox][selectedIndices[currentBox]]
-					newLocation := PromptForInput(screen, "Copy to:")
-					if newLocation != "" {
-						err := fileops.CopyFile(selectedFile.Name, newLocation)
-						if err != nil {
-							log.Println("Error copying file:", err)
-						} else {
-							log.Println("File copied successfully")
-						}
-					}
+				return items[selected], true
+			case tcell.KeyUp:
+				if selected > 0 {
+					selected--
 				}
-			} else {
-				if currentBox == 2 {
-					userInput = append(userInput, ev.Rune())
+			case tcell.KeyDown:
+				selected++
+			case tcell.KeyBackspace, tcell.KeyBackspace2:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+					selected = 0
 				}
+			case tcell.KeyRune:
+				input = append(input, ev.Rune())
+				selected = 0
 			}
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
+
+// State is the interactive session state shared by the main loop and the input
+// handler.
+type State struct {
+	CurrentBox      int
+	UserInput       []rune
+	SelectedIndices []int
+	ScrollPositions []int
+	BestMatch       *config.FileInfo
+	// Reload asks the main loop to read the directory again, after changing
+	// directory or modifying files.
+	Reload bool
+	Quit   bool
+
+	Picker PickerOptions
+	// Marked holds the absolute paths of the entries marked in picker mode.
+	Marked map[string]bool
+	// Picked is set to the chosen paths when an entry is picked.
+	Picked []string
+	// Preselect names a file the main loop should select once the directory
+	// has been read, used when jumping straight to a file.
+	Preselect string
+
+	Bookmarks *bookmarks.Bookmarks
+	// Layout is where the boxes were last drawn.
+	Layout ui.Layout
+	// LayoutTree is the layout in use, including any resizing done at runtime.
+	// LayoutName is the config layout it was made from.
+	LayoutTree *config.LayoutNode
+	LayoutName string
+	// Tree holds the expanded directories of the tree view.
+	Tree *utils.Tree
+	// Frecency records every visited directory, nil when disabled.
+	Frecency *frecency.DB
+	// Notifications holds the results of operations, shown as toasts.
+	Notifications *notifications.Queue
+	// Clipboard holds the entries yanked or cut, which survive changing
+	// directory and tab.
+	Clipboard *clipboard.Clipboard
+
+	// Commander shows the active and the other tab as two panes side by side.
+	Commander bool
+	// PreviewScroll is the first line of the file shown in the preview. The
+	// main loop resets it when the selection changes.
+	PreviewScroll int
+	// SortBy and SortReverse are the sort order of the listings. They start
+	// out as configured and can be changed in every tab.
+	SortBy      string
+	SortReverse bool
+
+	// Tabs holds every open tab. The fields above belong to the active one and
+	// are saved into Tabs[ActiveTab] when switching away from it.
+	Tabs        []*Tab
+	ActiveTab   int
+	previousTab int
+
+	back      []string
+	forward   []string
+	recent    []string
+	positions map[string]position
+	mouse     mouseState
+	keys      *Keymap
+	// pending holds the keys of a sequence typed so far, count the number
+	// typed before it.
+	pending []Key
+	count   int
+	// searchFrom is the box to return to when leaving the Search box.
+	searchFrom int
+	// startDir is the directory lds started in, which relative paths are
+	// copied relative to.
+	startDir string
+}
+
+// PickerOptions configure picker mode, where lds returns the chosen paths to
+// the caller instead of opening them.
+type PickerOptions struct {
+	Enabled   bool
+	Multi     bool
+	DirsOnly  bool
+	FilesOnly bool
+}
+
+func NewState(cfg *config.Config) *State {
+	s := &State{
+		CurrentBox:      2, // 2 = search box by default
+		SelectedIndices: []int{0, 0, 0, 0},
+		ScrollPositions: []int{0, 0, 0, 0},
+		Marked:          make(map[string]bool),
+		Tree:            utils.NewTree(),
+		positions:       make(map[string]position),
+		Notifications:   &notifications.Queue{},
+		SortBy:          cfg.Sort.By,
+		SortReverse:     cfg.Sort.Reverse,
+	}
+	if cfg.Frecency.Enabled {
+		s.Frecency = frecency.Open(cfg.Frecency.MaxAge)
+	}
+	cwd, _ := os.Getwd()
+	s.startDir = cwd
+	s.Tabs = []*Tab{{Dir: cwd}}
+	if cwd != "" {
+		s.addRecent(cwd)
+		s.recordVisit(cwd)
+	}
+	var err error
+	if s.Bookmarks, err = bookmarks.Load(); err != nil {
+		log.Println("Error loading bookmarks:", err)
+	}
+	if s.Clipboard, err = clipboard.New(cfg.Clipboard.Shared); err != nil {
+		log.Println("Error reading the clipboard:", err)
+	}
+	return s
+}
+
+// canPick reports whether the picker options allow file to be chosen.
+func (s *State) canPick(file config.FileInfo) bool {
+	isDir := file.FileType == "Directory"
+	return !(s.Picker.DirsOnly && !isDir) && !(s.Picker.FilesOnly && isDir)
+}
+
+// toggleMark marks or unmarks file. In picker mode without --multi a new mark
+// replaces the previous one.
+func (s *State) toggleMark(file config.FileInfo) {
+	if !s.canPick(file) {
+		return
+	}
+	path, err := filepath.Abs(file.Path)
+	if err != nil {
+		log.Println("Error resolving path:", err)
+		return
+	}
+	if s.Marked[path] {
+		delete(s.Marked, path)
+		return
+	}
+	if s.Picker.Enabled && !s.Picker.Multi {
+		clear(s.Marked)
+	}
+	s.Marked[path] = true
+}
+
+// pick finishes picker mode with the marked entries, or with file when nothing
+// is marked.
+func (s *State) pick(file *config.FileInfo) {
+	if len(s.Marked) > 0 {
+		for path := range s.Marked {
+			s.Picked = append(s.Picked, path)
+		}
+		slices.Sort(s.Picked)
+		s.Quit = true
+		return
+	}
+	if file == nil || !s.canPick(*file) {
+		return
+	}
+	path, err := filepath.Abs(file.Path)
+	if err != nil {
+		log.Println("Error resolving path:", err)
+		return
+	}
+	s.Picked = []string{path}
+	s.Quit = true
+}
+
+// ChangeDirectory moves the process into directory, or its parent when up is
+// set, and records the move in the navigation history.
+func (s *State) ChangeDirectory(directory string, up bool) {
+	from, _ := os.Getwd()
+	if err := utils.ChangeDirectory(directory, up); err != nil {
+		s.notifyError("Error changing directory: %v", err)
+		return
+	}
+	s.enterDirectory(from, true)
+}
+
+// promptForTarget asks where to copy or move a file to, with path completion.
+// With several tabs open Ctrl+O fills in the other tab's directory.
+func promptForTarget(screen tcell.Screen, state *State, prompt string) string {
+	var shortcuts map[tcell.Key]string
+	if dir := state.OtherTabDir(); dir != "" {
+		shortcuts = map[tcell.Key]string{tcell.KeyCtrlO: dir + string(filepath.Separator)}
+		prompt += " (Ctrl+O: other tab)"
+	}
+	return PromptWithCompletion(screen, prompt+": ", utils.CompletePath, shortcuts)
+}
+
+// resizeFocusedBox grows or shrinks the focused box along the nearest split
+// in the given direction of the layout tree.
+func resizeFocusedBox(state *State, split string, grow bool) {
+	if state.LayoutTree != nil {
+		ui.ResizeBox(state.LayoutTree, state.CurrentBox, split, grow)
+	}
+}
+
+// openInEditor hands the terminal over to the editor and takes it back once the
+// editor exits.
+func openInEditor(screen tcell.Screen, cfg *config.Config, fileName string) {
+	screen.Suspend()
+	fileops.OpenFileInEditor(cfg.PreferredEditor, fileName)
+	screen.Resume()
+}
+
+// HandleUserInput waits for the next event and handles it. Keys go through
+// the keymap, and the characters it leaves alone are typed into the Search box.
+func HandleUserInput(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	ev := screen.PollEvent()
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		if state.dispatchKey(screen, cfg, boxes, ev) {
+			return
+		}
+		if state.CurrentBox == 2 && ev.Key() == tcell.KeyRune {
+			state.UserInput = append(state.UserInput, ev.Rune())
 		}
+	case *tcell.EventMouse:
+		handleMouse(screen, cfg, state, boxes, ev)
 	case *tcell.EventResize:
 		screen.Sync()
 	}
-	return currentBox, userInput, selectedIndices, scrollPositions, bestMatch
+}
+
+// openSelection enters the selected directory or opens the selected file in
+// the editor, or picks it in picker mode.
+func openSelection(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo) {
+	currentBox := state.CurrentBox
+	selectedIndices := state.SelectedIndices
+	if state.Commander {
+		enterPaneEntry(screen, cfg, state, boxes[0], true)
+	} else if state.Picker.Enabled {
+		handlePickerEnter(state, boxes)
+	} else if currentBox == 2 && state.BestMatch != nil { // Search box
+		if state.BestMatch.FileType == "Directory" {
+			state.ChangeDirectory(state.BestMatch.Path, false)
+		} else {
+			openInEditor(screen, cfg, state.BestMatch.Path)
+		}
+	} else if currentBox == 1 && len(boxes[currentBox]) > 0 { // File box
+		selectedFile := boxes[currentBox][selectedIndices[currentBox]]
+		openInEditor(screen, cfg, selectedFile.Path)
+	} else if currentBox == 0 && len(boxes[currentBox]) > 0 { // Directory box
+		selectedFile := boxes[currentBox][selectedIndices[currentBox]]
+		state.ChangeDirectory(selectedFile.Path, false)
+	}
+}
+
+// handlePickerEnter picks the marked entries, or the highlighted file. Enter on
+// a directory still navigates into it, directories are picked by marking them.
+func handlePickerEnter(state *State, boxes [][]config.FileInfo) {
+	currentBox := state.CurrentBox
+	var selected *config.FileInfo
+	switch {
+	case currentBox == 2:
+		selected = state.BestMatch
+	case currentBox < len(boxes) && len(boxes[currentBox]) > 0:
+		selected = &boxes[currentBox][state.SelectedIndices[currentBox]]
+	}
+
+	if len(state.Marked) == 0 && selected != nil && selected.FileType == "Directory" {
+		state.ChangeDirectory(selected.Path, false)
+		return
+	}
+	state.pick(selected)
 }
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"fmt"
+	"lds/config"
+	"lds/ui"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// HelpText describes every key, grouped by where it works. The keys of the
+// actions come from the keyBindings in cfg, so they reflect the user's own.
+func HelpText(cfg *config.Config) []string {
+	keymap := NewKeymap(cfg)
+	var lines []string
+	section := func(title string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, title)
+	}
+	row := func(keys, description string) {
+		lines = append(lines, fmt.Sprintf("  %-22s %s", keys, description))
+	}
+	actionRow := func(action Action) {
+		keys := keymap.KeysFor(action.Name)
+		if keys == "" {
+			keys = "-"
+		}
+		row(keys, fmt.Sprintf("%s  [%s]", action.Title, action.Name))
+	}
+
+	var categories []string
+	for _, action := range actions {
+		if actionContexts[action.Name] == "" && !slices.Contains(categories, action.Category) {
+			categories = append(categories, action.Category)
+		}
+	}
+	for _, category := range categories {
+		section(category)
+		for _, action := range actions {
+			if action.Category == category && actionContexts[action.Name] == "" {
+				actionRow(action)
+			}
+		}
+	}
+
+	section("Directories and Files boxes")
+	for _, action := range actions {
+		if actionContexts[action.Name] == "list" {
+			actionRow(action)
+		}
+	}
+	row("Digits", "Repeat the next movement, as in 5 and Down")
+	row("Click / double-click", "Select / open an entry")
+	row("Drag a border", "Resize the boxes on both sides")
+
+	section("Search box")
+	row("Type", "Filter the Directories and Files boxes")
+	for _, action := range actions {
+		if actionContexts[action.Name] == "search" {
+			actionRow(action)
+		}
+	}
+	row(keymap.KeysFor("execute"), "Open the best match")
+
+	section("Preview")
+	row(keymap.KeysFor("previewUp")+" / "+keymap.KeysFor("previewDown"), "Scroll the preview")
+	row("Mouse wheel", "Scroll the preview or a list")
+
+	section("Prompts and lists")
+	row("Enter", "Accept")
+	row("Esc", "Cancel")
+	row("Tab", "Complete the path")
+	row("Up / Down", "Pick a candidate")
+	row("Ctrl+O", "Insert the other tab's directory in copy and move prompts")
+
+	section("Commander mode")
+	for _, action := range actions {
+		if actionContexts[action.Name] == "commander" {
+			actionRow(action)
+		}
+	}
+	row(keymap.KeysFor("selectUp")+" / "+keymap.KeysFor("selectDown"), "Select the previous / next entry")
+	row(keymap.KeysFor("execute")+", "+keymap.KeysFor("expand"), "Open the selected directory, Enter opens files too")
+	row(keymap.KeysFor("collapse"), "Go to the parent directory")
+	return lines
+}
+
+// showHelp shows HelpText in a popup.
+func showHelp(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+	showText(screen, cfg, state, "Keys", HelpText(cfg), "help")
+}
+
+// showText shows lines in a popup that scrolls with the arrow keys and closes
+// with Esc, q or the key of the action that opened it.
+func showText(screen tcell.Screen, cfg *config.Config, state *State, title string, lines []string, action string) {
+	style, borderStyle := ui.PopupStyles(cfg)
+	scroll := 0
+	for {
+		_, height := screen.Size()
+		page := max(height*2/3-2, 1)
+		scroll = max(min(scroll, len(lines)-page), 0)
+		ui.DrawTextPopup(screen, title, lines, scroll, style, borderStyle)
+		screen.Show()
+
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventKey:
+			if name, _ := state.keymap(cfg).Lookup([]Key{keyOf(ev)}, ""); name == action {
+				return
+			}
+			switch {
+			case ev.Key() == tcell.KeyEscape, ev.Key() == tcell.KeyEnter, ev.Key() == tcell.KeyCtrlC, ev.Rune() == 'q':
+				return
+			case ev.Key() == tcell.KeyUp, ev.Rune() == 'k':
+				scroll--
+			case ev.Key() == tcell.KeyDown, ev.Rune() == 'j':
+				scroll++
+			case ev.Key() == tcell.KeyPgUp:
+				scroll -= page
+			case ev.Key() == tcell.KeyPgDn, ev.Rune() == ' ':
+				scroll += page
+			case ev.Key() == tcell.KeyHome:
+				scroll = 0
+			case ev.Key() == tcell.KeyEnd:
+				scroll = len(lines)
+			}
+		case *tcell.EventMouse:
+			if ev.Buttons()&tcell.WheelUp != 0 {
+				scroll -= wheelStep
+			} else if ev.Buttons()&tcell.WheelDown != 0 {
+				scroll += wheelStep
+			}
+		case *tcell.EventResize:
+			screen.Sync()
+		}
+	}
+}
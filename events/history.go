@@ -0,0 +1,164 @@
+package events
+
+import (
+	"lds/config"
+	"lds/utils"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxRecent caps the number of directories kept for the history popup.
+const maxRecent = 100
+
+// position is the selection and scroll state of a directory, restored when the
+// directory is visited again.
+type position struct {
+	selectedIndices []int
+	scrollPositions []int
+}
+
+// GoBack returns to the directory visited before the current one.
+func (s *State) GoBack() {
+	if len(s.back) == 0 {
+		return
+	}
+	from, _ := os.Getwd()
+	target := s.back[len(s.back)-1]
+	if err := os.Chdir(target); err != nil {
+		s.notifyError("Error changing directory: %v", err)
+		return
+	}
+	s.back = s.back[:len(s.back)-1]
+	s.forward = append(s.forward, from)
+	s.enterDirectory(from, false)
+}
+
+// GoForward undoes a GoBack.
+func (s *State) GoForward() {
+	if len(s.forward) == 0 {
+		return
+	}
+	from, _ := os.Getwd()
+	target := s.forward[len(s.forward)-1]
+	if err := os.Chdir(target); err != nil {
+		s.notifyError("Error changing directory: %v", err)
+		return
+	}
+	s.forward = s.forward[:len(s.forward)-1]
+	s.back = append(s.back, from)
+	s.enterDirectory(from, false)
+}
+
+// enterDirectory is called after the working directory changed. It remembers
+// where the selection was in the directory that was left, restores it for the
+// new one and, when record is set, pushes the old directory on the back stack.
+func (s *State) enterDirectory(from string, record bool) {
+	s.positions[from] = position{
+		selectedIndices: slices.Clone(s.SelectedIndices),
+		scrollPositions: slices.Clone(s.ScrollPositions),
+	}
+	if record {
+		s.back = append(s.back, from)
+		s.forward = nil
+	}
+
+	to, _ := os.Getwd()
+	s.addRecent(to)
+	s.recordVisit(to)
+	if p, ok := s.positions[to]; ok {
+		s.SelectedIndices = slices.Clone(p.selectedIndices)
+		s.ScrollPositions = slices.Clone(p.scrollPositions)
+	} else {
+		s.SelectedIndices = []int{0, 0, 0, 0}
+		s.ScrollPositions = []int{0, 0, 0, 0}
+	}
+	s.UserInput = nil
+	s.BestMatch = nil
+	s.Tree = utils.NewTree()
+	s.Reload = true
+}
+
+// addRecent moves dir to the front of the recent directories.
+func (s *State) addRecent(dir string) {
+	s.recent = slices.DeleteFunc(s.recent, func(d string) bool { return d == dir })
+	s.recent = slices.Insert(s.recent, 0, dir)
+	if len(s.recent) > maxRecent {
+		s.recent = s.recent[:maxRecent]
+	}
+}
+
+// recentCandidates lists the recent directories other than the current one that
+// contain query.
+func (s *State) recentCandidates(query string) []string {
+	cwd, _ := os.Getwd()
+	var candidates []string
+	for _, dir := range s.recent {
+		if dir != cwd && strings.Contains(strings.ToLower(dir), strings.ToLower(query)) {
+			candidates = append(candidates, dir)
+		}
+	}
+	return candidates
+}
+
+// recordVisit adds dir to the frecency database.
+func (s *State) recordVisit(dir string) {
+	if s.Frecency == nil {
+		return
+	}
+	if err := s.Frecency.Add(dir); err != nil {
+		log.Println("Error recording visit:", err)
+	}
+}
+
+// jumpByFrecency prompts for keywords and jumps to the best ranked directory
+// in the frecency database that matches them.
+func jumpByFrecency(screen tcell.Screen, cfg *config.Config, state *State) {
+	if state.Frecency == nil {
+		state.notifyWarning("Frecency jumping is disabled in the config")
+		return
+	}
+	candidates := func(query string) []string {
+		dirs, err := state.Frecency.Query(strings.Fields(query))
+		if err != nil {
+			state.notifyError("Error querying frecency database: %v", err)
+		}
+		return dirs
+	}
+	if dir, ok := SelectFromList(screen, cfg, "Jump to directory", candidates); ok {
+		state.ChangeDirectory(dir, false)
+	}
+}
+
+// goToPath prompts for a path, with ~ expansion and Tab completion, and jumps
+// to it. For a file lds changes to its parent directory and selects the file.
+func goToPath(screen tcell.Screen, state *State) {
+	if input := PromptWithCompletion(screen, "Go to: ", utils.CompletePath, nil); input != "" {
+		state.goTo(input)
+	}
+}
+
+// goTo changes to the directory at path, or to the directory of the file at
+// path with the file selected.
+func (s *State) goTo(input string) {
+	path, err := utils.ExpandPath(input)
+	if err != nil {
+		s.notifyError("Error expanding path: %v", err)
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		s.notifyError("Error going to path: %v", err)
+		return
+	}
+	if info.IsDir() {
+		s.ChangeDirectory(path, false)
+		return
+	}
+	s.ChangeDirectory(filepath.Dir(path), false)
+	s.Preselect = filepath.Base(path)
+}
@@ -0,0 +1,572 @@
+package events
+
+import (
+	"fmt"
+	"lds/config"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Key is a key press as it is written in the keyBindings section of the
+// config, such as "Alt+r", "Ctrl+P", "Shift+Tab" or "F5". Letters keep their
+// case instead of carrying Shift, so "Alt+B" is Alt with a capital B.
+type Key struct {
+	Code tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// keyNames are the names of the special keys, in the spelling String uses.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEnter:      "Enter",
+	tcell.KeyTab:        "Tab",
+	tcell.KeyBackspace2: "Backspace",
+	tcell.KeyEscape:     "Esc",
+	tcell.KeyUp:         "Up",
+	tcell.KeyDown:       "Down",
+	tcell.KeyLeft:       "Left",
+	tcell.KeyRight:      "Right",
+	tcell.KeyHome:       "Home",
+	tcell.KeyEnd:        "End",
+	tcell.KeyPgUp:       "PgUp",
+	tcell.KeyPgDn:       "PgDn",
+	tcell.KeyInsert:     "Insert",
+	tcell.KeyDelete:     "Delete",
+}
+
+// keyAliases are other accepted spellings of the special keys.
+var keyAliases = map[string]tcell.Key{
+	"return":    tcell.KeyEnter,
+	"escape":    tcell.KeyEscape,
+	"pageup":    tcell.KeyPgUp,
+	"pagedown":  tcell.KeyPgDn,
+	"del":       tcell.KeyDelete,
+	"ins":       tcell.KeyInsert,
+	"backtab":   tcell.KeyBacktab,
+	"backspace": tcell.KeyBackspace2,
+}
+
+// ParseKey parses a key such as "Ctrl+Alt+Left". Modifiers and key names are
+// not case sensitive, single characters are.
+func ParseKey(s string) (Key, error) {
+	s = strings.TrimSpace(s)
+	var key Key
+	for {
+		// A trailing "+" is the key itself, as in "Alt++"
+		i := strings.Index(s, "+")
+		if i <= 0 || i == len(s)-1 {
+			break
+		}
+		switch strings.ToLower(s[:i]) {
+		case "ctrl", "control":
+			key.Mod |= tcell.ModCtrl
+		case "alt":
+			key.Mod |= tcell.ModAlt
+		case "shift":
+			key.Mod |= tcell.ModShift
+		case "meta", "cmd":
+			key.Mod |= tcell.ModMeta
+		default:
+			return Key{}, fmt.Errorf("unknown modifier %q in %q", s[:i], s)
+		}
+		s = s[i+1:]
+	}
+
+	if r, size := utf8.DecodeRuneInString(s); size == len(s) && r != utf8.RuneError {
+		switch {
+		case key.Mod&tcell.ModCtrl != 0 && unicode.IsLetter(r) && r < unicode.MaxASCII:
+			key.Code = tcell.KeyCtrlA + tcell.Key(unicode.ToLower(r)-'a')
+		case key.Mod&tcell.ModShift != 0:
+			key.Code, key.Rune = tcell.KeyRune, unicode.ToUpper(r)
+			key.Mod &^= tcell.ModShift
+		default:
+			key.Code, key.Rune = tcell.KeyRune, r
+		}
+		return key, nil
+	}
+
+	name := strings.ToLower(s)
+	if name == "space" {
+		key.Code, key.Rune = tcell.KeyRune, ' '
+		return key, nil
+	}
+	var n int
+	if _, err := fmt.Sscanf(name, "f%d", &n); err == nil && n >= 1 && n <= 24 {
+		key.Code = tcell.KeyF1 + tcell.Key(n-1)
+		return key, nil
+	}
+	if code, ok := keyAliases[name]; ok {
+		key.Code = code
+	} else {
+		for code, keyName := range keyNames {
+			if strings.ToLower(keyName) == name {
+				key.Code = code
+			}
+		}
+	}
+	if key.Code == 0 {
+		return Key{}, fmt.Errorf("unknown key %q", s)
+	}
+	if key.Code == tcell.KeyTab && key.Mod&tcell.ModShift != 0 {
+		key.Code = tcell.KeyBacktab
+	}
+	if key.Code == tcell.KeyBacktab {
+		key.Mod &^= tcell.ModShift
+	}
+	return key, nil
+}
+
+// keyOf turns a key event into a Key, smoothing over the ways terminals
+// report the same key.
+func keyOf(ev *tcell.EventKey) Key {
+	key := Key{Code: ev.Key(), Mod: ev.Modifiers()}
+	switch {
+	case key.Code == tcell.KeyRune:
+		key.Rune = ev.Rune()
+		key.Mod &^= tcell.ModShift
+	case key.Code == tcell.KeyBackspace && key.Mod == tcell.ModNone:
+		key.Code = tcell.KeyBackspace2
+	case key.Code == tcell.KeyBacktab:
+		key.Mod &^= tcell.ModShift
+	case key.Code >= tcell.KeyCtrlA && key.Code <= tcell.KeyCtrlZ &&
+		key.Code != tcell.KeyTab && key.Code != tcell.KeyEnter && key.Code != tcell.KeyBackspace:
+		key.Mod |= tcell.ModCtrl
+	}
+	return key
+}
+
+// typesText reports whether key is a character that the Search box takes as
+// input rather than a command.
+func (k Key) typesText() bool {
+	return k.Code == tcell.KeyRune && k.Mod&^tcell.ModShift == 0
+}
+
+func (k Key) String() string {
+	var parts []string
+	if k.Mod&tcell.ModCtrl != 0 {
+		parts = append(parts, "Ctrl")
+	}
+	if k.Mod&tcell.ModAlt != 0 {
+		parts = append(parts, "Alt")
+	}
+	if k.Mod&tcell.ModMeta != 0 {
+		parts = append(parts, "Meta")
+	}
+	if k.Mod&tcell.ModShift != 0 {
+		parts = append(parts, "Shift")
+	}
+	switch {
+	case k.Code == tcell.KeyRune && k.Rune == ' ':
+		parts = append(parts, "Space")
+	case k.Code == tcell.KeyRune:
+		parts = append(parts, string(k.Rune))
+	case k.Code == tcell.KeyBacktab:
+		parts = append(parts, "Shift", "Tab")
+	case k.Code >= tcell.KeyCtrlA && k.Code <= tcell.KeyCtrlZ && keyNames[k.Code] == "":
+		parts = append(parts, string(rune('A'+k.Code-tcell.KeyCtrlA)))
+	case k.Code >= tcell.KeyF1 && k.Code <= tcell.KeyF24:
+		parts = append(parts, fmt.Sprintf("F%d", k.Code-tcell.KeyF1+1))
+	default:
+		parts = append(parts, keyNames[k.Code])
+	}
+	return strings.Join(parts, "+")
+}
+
+// defaultBindings are the keys of the actions the config does not bind.
+// Several keys are separated by ", " and the keys of a sequence by spaces.
+var defaultBindings = map[string]string{
+	"quit":           "Ctrl+C",
+	"commandPalette": "Ctrl+P",
+	"help":           "?, F1",
+	"notifications":  "Alt+i",
+	"dismiss":        "Alt+e",
+	"nextBox":        "Tab",
+	"previousBox":    "Shift+Tab",
+	"selectUp":       "Up",
+	"selectDown":     "Down",
+	"selectFirst":    "Home",
+	"selectLast":     "End",
+	"collapse":       "Left",
+	"expand":         "Right",
+	"execute":        "Enter",
+	"goBack":         "Esc",
+	"focusSearch":    "/",
+	"backspace":      "Backspace",
+	"mark":           "Space",
+	"yank":           "Ctrl+Y",
+	"cut":            "Ctrl+X",
+	"paste":          "Ctrl+V",
+	"copyPath":       "Alt+p",
+	"historyBack":    "Alt+Left",
+	"historyForward": "Alt+Right",
+	"history":        "Alt+j",
+	"jump":           "Alt+z",
+	"goToPath":       "Alt+g",
+	"jumpToBookmark": "Alt+b",
+	"bookmarks":      "Alt+B",
+	"rename":         "Alt+r",
+	"move":           "Alt+m",
+	"delete":         "Alt+d",
+	"copy":           "Alt+c",
+	"toggleHidden":   "Alt+h",
+	"toggleListView": "Alt+l",
+	"toggleTreeView": "Alt+t",
+	"cycleLayout":    "Alt+v",
+	"shrinkWidth":    "Ctrl+Left",
+	"growWidth":      "Ctrl+Right",
+	"shrinkHeight":   "Ctrl+Up",
+	"growHeight":     "Ctrl+Down",
+	"cycleSort":      "Alt+s",
+	"previewUp":      "PgUp",
+	"previewDown":    "PgDn",
+	"newTab":         "Alt+n",
+	"closeTab":       "Alt+w",
+	"nextTab":        "Alt+.",
+	"previousTab":    "Alt+,",
+	"commander":      "Alt+x",
+	"leaveCommander": "Esc, F10",
+	"switchPane":     "Tab",
+	"pageUp":         "PgUp",
+	"pageDown":       "PgDn",
+	"paneParent":     "Backspace",
+	"markNext":       "Space, Insert",
+	"copyToPane":     "F5",
+	"moveToPane":     "F6",
+}
+
+// vimBindings replace the default keys when the keymap config option is
+// "vim". Movement works on the lists, / moves to the Search box and Esc back.
+var vimBindings = map[string]string{
+	"selectUp":         "k, Up",
+	"selectDown":       "j, Down",
+	"selectFirst":      "g g, Home",
+	"selectLast":       "G, End",
+	"collapse":         "h, Left",
+	"expand":           "l, Right",
+	"leaveSearch":      "Esc",
+	"nextMatch":        "n",
+	"previousMatch":    "N",
+	"yank":             "y y",
+	"cut":              "d d",
+	"paste":            "p",
+	"copyName":         "y n",
+	"copyPath":         "y p, Alt+p",
+	"copyRelativePath": "y r",
+	"copyParentDir":    "y d",
+	"commandLine":      ":",
+	"previewUp":        "Ctrl+U, PgUp",
+	"previewDown":      "Ctrl+D, PgDn",
+	"pageUp":           "Ctrl+B, PgUp",
+	"pageDown":         "Ctrl+F, PgDn",
+}
+
+func init() {
+	for n := 1; n <= 9; n++ {
+		defaultBindings[fmt.Sprintf("goToTab%d", n)] = fmt.Sprintf("Alt+%d", n)
+	}
+}
+
+// Keymap maps keys, and sequences of keys such as "g g", to the actions bound
+// to them.
+type Keymap struct {
+	cfg *config.Config
+	// bindings holds the actions of every sequence. A sequence can be bound
+	// to several actions that work in different boxes.
+	bindings map[string][]string
+	// prefixes holds the sequences that the next key may complete.
+	prefixes map[string]bool
+	keys     map[string][][]Key
+}
+
+// NewKeymap binds the keys set in cfg's keyBindings on top of the preset
+// chosen with the keymap option. Entries that just repeat the default key do
+// not override the preset, so the sample config works with any preset.
+func NewKeymap(cfg *config.Config) *Keymap {
+	keymap := &Keymap{cfg: cfg, bindings: map[string][]string{}, prefixes: map[string]bool{}, keys: map[string][][]Key{}}
+	for name := range cfg.KeyBindings {
+		if _, ok := findAction(name); !ok {
+			log.Printf("Unknown action %q in keyBindings", name)
+		}
+	}
+	if cfg.Keymap != "" && cfg.Keymap != "default" && cfg.Keymap != "vim" {
+		log.Printf("Unknown keymap %q, using the default keys", cfg.Keymap)
+	}
+
+	for _, action := range actions {
+		binding := cfg.KeyBindings[action.Name]
+		if binding == "" || binding == defaultBindings[action.Name] {
+			if navigation := navigationBinding(cfg, action.Name); navigation != "" {
+				binding = navigation
+			}
+		}
+		if binding == "" || binding == defaultBindings[action.Name] {
+			binding = defaultBindings[action.Name]
+			if preset, ok := vimBindings[action.Name]; ok && cfg.Keymap == "vim" {
+				binding = preset
+			}
+		}
+
+		for _, s := range strings.Split(binding, ", ") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			var sequence []Key
+			for _, part := range strings.Fields(s) {
+				key, err := ParseKey(part)
+				if err != nil {
+					log.Printf("Invalid key binding for %s: %v", action.Name, err)
+					sequence = nil
+					break
+				}
+				sequence = append(sequence, key)
+			}
+			if sequence == nil {
+				continue
+			}
+			id := sequenceID(sequence)
+			for _, other := range keymap.bindings[id] {
+				if actionsOverlap(other, action.Name) {
+					log.Printf("%s is bound to both %s and %s, using %s", s, other, action.Name, other)
+				}
+			}
+			keymap.bindings[id] = append(keymap.bindings[id], action.Name)
+			keymap.keys[action.Name] = append(keymap.keys[action.Name], sequence)
+			for i := 1; i < len(sequence); i++ {
+				keymap.prefixes[sequenceID(sequence[:i])] = true
+			}
+		}
+	}
+	return keymap
+}
+
+// navigationBinding returns the key that the navigation section of cfg sets
+// for the named action, if any. It is used unless keyBindings changes the
+// default key of the action.
+func navigationBinding(cfg *config.Config, name string) string {
+	switch name {
+	case "selectUp":
+		return cfg.Navigation.Up
+	case "selectDown":
+		return cfg.Navigation.Down
+	case "collapse":
+		return cfg.Navigation.Left
+	case "expand":
+		return cfg.Navigation.Right
+	}
+	return ""
+}
+
+func sequenceID(sequence []Key) string {
+	var parts []string
+	for _, key := range sequence {
+		parts = append(parts, key.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Lookup returns the action bound to sequence in context, one of the values
+// of actionContexts. prefix is set when sequence is the start of a longer one
+// instead.
+func (k *Keymap) Lookup(sequence []Key, context string) (name string, prefix bool) {
+	for _, name := range k.bindings[sequenceID(sequence)] {
+		if activeIn(name, context) {
+			return name, false
+		}
+	}
+	return "", k.prefixes[sequenceID(sequence)]
+}
+
+// KeysFor returns the keys bound to the named action, joined with ", ".
+// Sequences of plain characters are written together, as in "gg".
+func (k *Keymap) KeysFor(name string) string {
+	var keys []string
+	for _, sequence := range k.keys[name] {
+		plain := true
+		for _, key := range sequence {
+			plain = plain && key.typesText() && key.Rune != ' '
+		}
+		if plain && len(sequence) > 1 {
+			var text []rune
+			for _, key := range sequence {
+				text = append(text, key.Rune)
+			}
+			keys = append(keys, string(text))
+		} else {
+			keys = append(keys, sequenceID(sequence))
+		}
+	}
+	return strings.Join(keys, ", ")
+}
+
+// actionContexts limits actions to the Directories and Files boxes ("list"),
+// to the Search box ("search") or to commander mode ("commander"). The others
+// work everywhere except in commander mode, which only takes its own actions
+// and those in commanderActions.
+var actionContexts = map[string]string{
+	"goBack":         "list",
+	"collapse":       "list",
+	"expand":         "list",
+	"selectFirst":    "list",
+	"selectLast":     "list",
+	"focusSearch":    "list",
+	"mark":           "list",
+	"nextMatch":      "list",
+	"previousMatch":  "list",
+	"yank":           "list",
+	"cut":            "list",
+	"paste":          "list",
+	"backspace":      "search",
+	"leaveSearch":    "search",
+	"leaveCommander": "commander",
+	"switchPane":     "commander",
+	"pageUp":         "commander",
+	"pageDown":       "commander",
+	"paneParent":     "commander",
+	"markNext":       "commander",
+	"copyToPane":     "commander",
+	"moveToPane":     "commander",
+}
+
+// commanderActions are the actions of the other contexts that work in
+// commander mode as well, on the active pane.
+var commanderActions = map[string]bool{
+	"quit":             true,
+	"help":             true,
+	"notifications":    true,
+	"dismiss":          true,
+	"selectUp":         true,
+	"selectDown":       true,
+	"selectFirst":      true,
+	"selectLast":       true,
+	"collapse":         true,
+	"expand":           true,
+	"execute":          true,
+	"toggleHidden":     true,
+	"toggleGitIgnored": true,
+	"cycleSort":        true,
+	"sortByName":       true,
+	"sortBySize":       true,
+	"sortByTime":       true,
+	"sortByExtension":  true,
+	"reverseSort":      true,
+	"commander":        true,
+}
+
+// activeIn reports whether the named action works in context, one of the
+// values of actionContexts. An empty context is a box that takes any action.
+func activeIn(name, context string) bool {
+	if context == "commander" {
+		return actionContexts[name] == "commander" || commanderActions[name]
+	}
+	return actionContexts[name] != "commander" && (actionContexts[name] == "" || context == "" || actionContexts[name] == context)
+}
+
+// actionsOverlap reports whether the actions a and b work in a common context,
+// where they cannot share a key.
+func actionsOverlap(a, b string) bool {
+	for _, context := range []string{"list", "search", "commander"} {
+		if activeIn(a, context) && activeIn(b, context) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatedActions run as many times as the count typed before their key, as
+// in "5j". selectFirst and selectLast use the count as a row number instead.
+var repeatedActions = map[string]bool{
+	"selectUp":      true,
+	"selectDown":    true,
+	"previewUp":     true,
+	"previewDown":   true,
+	"nextMatch":     true,
+	"previousMatch": true,
+	"pageUp":        true,
+	"pageDown":      true,
+}
+
+// keymap returns the keymap for cfg, which is built again whenever the config
+// is reloaded.
+func (s *State) keymap(cfg *config.Config) *Keymap {
+	if s.keys == nil || s.keys.cfg != cfg {
+		s.keys = NewKeymap(cfg)
+	}
+	return s.keys
+}
+
+// KeysFor returns the keys bound to the named action in the keymap of cfg,
+// joined with ", ".
+func (s *State) KeysFor(cfg *config.Config, name string) string {
+	return s.keymap(cfg).KeysFor(name)
+}
+
+// dispatchKey runs the action bound to the key of ev, keeping track of counts
+// and of sequences that have been started. It reports whether the key was
+// used, otherwise it is text for the Search box.
+func (s *State) dispatchKey(screen tcell.Screen, cfg *config.Config, boxes [][]config.FileInfo, ev *tcell.EventKey) bool {
+	key := keyOf(ev)
+	context := ""
+	switch {
+	case s.Commander:
+		context = "commander"
+	case s.CurrentBox == 0, s.CurrentBox == 1:
+		context = "list"
+	case s.CurrentBox == 2:
+		context = "search"
+	}
+	if len(s.pending) == 0 && context == "search" && key.typesText() {
+		return false
+	}
+
+	keymap := s.keymap(cfg)
+	sequence := append(s.pending, key)
+	name, prefix := keymap.Lookup(sequence, context)
+	if name == "" && !prefix && len(s.pending) == 0 && (context == "list" || context == "commander") && key.typesText() &&
+		key.Rune >= '0' && key.Rune <= '9' && (key.Rune != '0' || s.count > 0) {
+		s.count = s.count*10 + int(key.Rune-'0')
+		return true
+	}
+	if prefix {
+		s.pending = sequence
+		return true
+	}
+
+	// A key that does not continue a sequence or count cancels it
+	used := len(s.pending) > 0 || s.count > 0
+	s.pending = nil
+	if name == "" {
+		s.count = 0
+		return used
+	}
+	times := 1
+	if repeatedActions[name] && s.count > 0 {
+		times = s.count
+	}
+	for range times {
+		runAction(name, screen, cfg, s, boxes)
+	}
+	s.count = 0
+	return true
+}
+
+// PendingKeys returns the count and the keys of a sequence typed so far, as
+// in "5g", or "" when none are pending.
+func (s *State) PendingKeys() string {
+	text := ""
+	if s.count > 0 {
+		text = strconv.Itoa(s.count)
+	}
+	for _, key := range s.pending {
+		if key.typesText() {
+			text += string(key.Rune)
+		} else {
+			text += "<" + key.String() + ">"
+		}
+	}
+	return text
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"lds/config"
+	"lds/ui"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// doubleClickTime is how quickly a second click on the same row has to follow
+// the first to count as a double-click.
+const doubleClickTime = 400 * time.Millisecond
+
+// wheelStep is the number of rows a turn of the mouse wheel scrolls.
+const wheelStep = 3
+
+// mouseState remembers what the previous mouse events were about, since tcell
+// reports the buttons held down rather than presses and releases.
+type mouseState struct {
+	buttons   tcell.ButtonMask
+	lastClick time.Time
+	lastX     int
+	lastY     int
+	// drag is the split border being dragged, nil when there is none.
+	drag *ui.Split
+}
+
+// press reports whether ev is the left button going down, and whether that is
+// the second click of a double-click.
+func (m *mouseState) press(ev *tcell.EventMouse) (pressed, double bool) {
+	buttons := ev.Buttons()
+	pressed = buttons&tcell.Button1 != 0 && m.buttons&tcell.Button1 == 0
+	m.buttons = buttons
+	if !pressed {
+		return false, false
+	}
+	x, y := ev.Position()
+	double = x == m.lastX && y == m.lastY && time.Since(m.lastClick) < doubleClickTime
+	if double {
+		m.lastClick = time.Time{}
+	} else {
+		m.lastClick, m.lastX, m.lastY = time.Now(), x, y
+	}
+	return true, double
+}
+
+// handleMouse focuses the box that is clicked and selects the row under the
+// cursor, opens it on a double-click, scrolls with the wheel and resizes the
+// layout when a border between boxes is dragged.
+func handleMouse(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo, ev *tcell.EventMouse) {
+	x, y := ev.Position()
+	pressed, double := state.mouse.press(ev)
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		scrollAt(state, boxes, x, y, -wheelStep)
+	case ev.Buttons()&tcell.WheelDown != 0:
+		scrollAt(state, boxes, x, y, wheelStep)
+	case pressed:
+		if len(state.Tabs) > 1 && y == 0 {
+			if index := ui.TabAt(state.TabTitles(), x); index >= 0 {
+				state.SwitchTab(index)
+			}
+			return
+		}
+		if split, ok := state.Layout.SplitAt(x, y); ok {
+			state.mouse.drag = &split
+			return
+		}
+		clickBox(screen, cfg, state, boxes, x, y, double)
+	case ev.Buttons()&tcell.Button1 != 0 && state.mouse.drag != nil:
+		if state.mouse.drag.Columns {
+			state.mouse.drag.Drag(x)
+		} else {
+			state.mouse.drag.Drag(y)
+		}
+	case ev.Buttons()&tcell.Button1 == 0:
+		state.mouse.drag = nil
+	}
+}
+
+// clickBox focuses the box at x, y and selects the row clicked in the
+// Directories and Files boxes. A double-click then acts like Enter.
+func clickBox(screen tcell.Screen, cfg *config.Config, state *State, boxes [][]config.FileInfo, x, y int, double bool) {
+	box := state.Layout.BoxAt(x, y)
+	if box < 0 {
+		return
+	}
+	state.CurrentBox = box
+	r := state.Layout.Boxes[box]
+	if box >= len(boxes) || y == r.Y || y == r.Y+r.Height-1 {
+		return
+	}
+	if box == 2 {
+		if double {
+			openSelection(screen, cfg, state, boxes)
+		}
+		return
+	}
+	row := state.ScrollPositions[box] + y - r.Y - 1
+	if row >= len(boxes[box]) {
+		return
+	}
+	state.SelectedIndices[box] = row
+	if double {
+		openSelection(screen, cfg, state, boxes)
+	}
+}
+
+// scrollAt scrolls whatever is under x, y by delta rows: the preview, or the
+// Directories or Files box, in which case the selection is kept in view.
+func scrollAt(state *State, boxes [][]config.FileInfo, x, y, delta int) {
+	layout := state.Layout
+	if layout.Preview.Contains(x, y) || (layout.Preview.Empty() && state.CurrentBox == 1 && layout.Boxes[0].Contains(x, y)) {
+		state.PreviewScroll = max(state.PreviewScroll+delta, 0)
+		return
+	}
+	box := layout.BoxAt(x, y)
+	if box != 0 && box != 1 {
+		return
+	}
+	rows := max(layout.Boxes[box].Height-2, 1)
+	// The arrow keys keep the selection off the last row, so do the same
+	scroll := max(min(state.ScrollPositions[box]+delta, len(boxes[box])-rows+1), 0)
+	state.ScrollPositions[box] = scroll
+	selected := &state.SelectedIndices[box]
+	*selected = max(min(max(*selected, scroll), scroll+max(rows-2, 0), len(boxes[box])-1), 0)
+}
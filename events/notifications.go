@@ -0,0 +1,67 @@
+package events
+
+import (
+	"fmt"
+	"lds/config"
+	"lds/notifications"
+	"log"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// defaultNotificationDuration is how long notifications stay on screen when
+// notifications.duration is not set.
+const defaultNotificationDuration = 5 * time.Second
+
+// maxToasts is the number of notifications shown at once.
+const maxToasts = 4
+
+// Notify logs a message and shows it as a notification.
+func (s *State) Notify(severity notifications.Severity, format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	log.Println(text)
+	s.Notifications.Post(severity, text)
+}
+
+func (s *State) notify(format string, args ...any) {
+	s.Notify(notifications.Info, format, args...)
+}
+
+func (s *State) notifySuccess(format string, args ...any) {
+	s.Notify(notifications.Success, format, args...)
+}
+
+func (s *State) notifyWarning(format string, args ...any) {
+	s.Notify(notifications.Warning, format, args...)
+}
+
+func (s *State) notifyError(format string, args ...any) {
+	s.Notify(notifications.Error, format, args...)
+}
+
+// Toasts returns the notifications to show in the corner of the screen. They
+// disappear after the duration set in the notifications section of the
+// config, except for errors, and not at all when notifications are disabled.
+func (s *State) Toasts(cfg *config.Config) []notifications.Notification {
+	if !cfg.Notifications.Enabled {
+		return nil
+	}
+	duration := time.Duration(cfg.Notifications.Duration) * time.Second
+	if duration <= 0 {
+		duration = defaultNotificationDuration
+	}
+	return s.Notifications.Visible(duration, maxToasts)
+}
+
+// showNotifications lists every notification, newest first.
+func showNotifications(screen tcell.Screen, cfg *config.Config, state *State, _ [][]config.FileInfo) {
+	var lines []string
+	for _, n := range state.Notifications.History() {
+		lines = append(lines, fmt.Sprintf("  %s  %-7s  %s", n.Time.Format("15:04:05"), n.Severity, n.Text))
+	}
+	if len(lines) == 0 {
+		lines = []string{"  No notifications yet"}
+	}
+	showText(screen, cfg, state, "Notifications", lines, "notifications")
+}
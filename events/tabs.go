@@ -0,0 +1,123 @@
+package events
+
+import (
+	"lds/config"
+	"lds/utils"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// Tab is a working directory kept open next to the current one. The active
+// tab lives in State itself; a Tab holds what is saved while it is inactive.
+type Tab struct {
+	Dir             string
+	UserInput       []rune
+	SelectedIndices []int
+	ScrollPositions []int
+	CurrentBox      int
+	SortBy          string
+	SortReverse     bool
+
+	back    []string
+	forward []string
+	tree    *utils.Tree
+}
+
+// NewTab opens a tab in the current directory and switches to it.
+func (s *State) NewTab(cfg *config.Config) {
+	s.saveTab()
+	dir, _ := os.Getwd()
+	s.Tabs = append(s.Tabs, &Tab{
+		Dir:             dir,
+		SelectedIndices: []int{0, 0, 0, 0},
+		ScrollPositions: []int{0, 0, 0, 0},
+		CurrentBox:      2,
+		SortBy:          cfg.Sort.By,
+		SortReverse:     cfg.Sort.Reverse,
+		tree:            utils.NewTree(),
+	})
+	s.previousTab = s.ActiveTab
+	s.loadTab(len(s.Tabs) - 1)
+}
+
+// CloseTab closes the active tab, unless it is the last one.
+func (s *State) CloseTab() {
+	if len(s.Tabs) < 2 {
+		return
+	}
+	s.Tabs = slices.Delete(s.Tabs, s.ActiveTab, s.ActiveTab+1)
+	s.previousTab = 0
+	s.loadTab(min(s.ActiveTab, len(s.Tabs)-1))
+}
+
+// SwitchTab makes the tab at index active, wrapping around at both ends.
+func (s *State) SwitchTab(index int) {
+	if len(s.Tabs) < 2 {
+		return
+	}
+	index = (index + len(s.Tabs)) % len(s.Tabs)
+	if index == s.ActiveTab {
+		return
+	}
+	s.saveTab()
+	s.previousTab = s.ActiveTab
+	s.loadTab(index)
+}
+
+// OtherTabDir returns the directory of the tab that was active before the
+// current one, which copy and move offer as their target. It is empty when
+// only one tab is open.
+func (s *State) OtherTabDir() string {
+	if len(s.Tabs) < 2 {
+		return ""
+	}
+	return s.Tabs[s.otherTab()].Dir
+}
+
+// TabTitles returns the label of every tab, for the tab bar.
+func (s *State) TabTitles() []string {
+	titles := make([]string, len(s.Tabs))
+	for i, tab := range s.Tabs {
+		dir := tab.Dir
+		if i == s.ActiveTab {
+			dir, _ = os.Getwd()
+		}
+		name := filepath.Base(dir)
+		if name == string(filepath.Separator) || name == "." {
+			name = dir
+		}
+		titles[i] = strconv.Itoa(i+1) + " " + name
+	}
+	return titles
+}
+
+func (s *State) saveTab() {
+	tab := s.Tabs[s.ActiveTab]
+	tab.Dir, _ = os.Getwd()
+	tab.UserInput = s.UserInput
+	tab.SelectedIndices = s.SelectedIndices
+	tab.ScrollPositions = s.ScrollPositions
+	tab.CurrentBox = s.CurrentBox
+	tab.SortBy, tab.SortReverse = s.SortBy, s.SortReverse
+	tab.back, tab.forward = s.back, s.forward
+	tab.tree = s.Tree
+}
+
+func (s *State) loadTab(index int) {
+	tab := s.Tabs[index]
+	s.ActiveTab = index
+	if err := os.Chdir(tab.Dir); err != nil {
+		s.notifyError("Error changing directory: %v", err)
+	}
+	s.UserInput = tab.UserInput
+	s.SelectedIndices = slices.Clone(tab.SelectedIndices)
+	s.ScrollPositions = slices.Clone(tab.ScrollPositions)
+	s.CurrentBox = tab.CurrentBox
+	s.SortBy, s.SortReverse = tab.SortBy, tab.SortReverse
+	s.back, s.forward = tab.back, tab.forward
+	s.Tree = tab.tree
+	s.BestMatch = nil
+	s.Reload = true
+}
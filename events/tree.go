@@ -0,0 +1,44 @@
+package events
+
+import (
+	"lds/config"
+	"path/filepath"
+)
+
+// expandOrEnter expands the selected directory of the tree view, or moves to
+// its first child when it is already expanded.
+func expandOrEnter(state *State, dirs []config.FileInfo) {
+	index := state.SelectedIndices[0]
+	if index >= len(dirs) {
+		return
+	}
+	dir := dirs[index]
+	if !state.Tree.IsExpanded(dir.Path) {
+		state.Tree.Expand(dir.Path)
+		return
+	}
+	if index+1 < len(dirs) && filepath.Dir(dirs[index+1].Path) == dir.Path {
+		selectEntry(state, 0, index+1)
+	}
+}
+
+// collapseOrLeave collapses the selected directory of the tree view, or moves
+// to its parent when it is already collapsed.
+func collapseOrLeave(state *State, dirs []config.FileInfo) {
+	index := state.SelectedIndices[0]
+	if index >= len(dirs) {
+		return
+	}
+	dir := dirs[index]
+	if state.Tree.IsExpanded(dir.Path) {
+		state.Tree.Collapse(dir.Path)
+		return
+	}
+	parent := filepath.Dir(dir.Path)
+	for i := index - 1; i >= 0; i-- {
+		if dirs[i].Path == parent {
+			selectEntry(state, 0, i)
+			return
+		}
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"strings"
 )
 
 func OpenFileInEditor(editor, fileName string) {
@@ -24,36 +24,53 @@ func OpenFileInEditor(editor, fileName string) {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running editor: %v\n", err)
 	}
-	exec.Command("sh", "-c", "lds").Run()
+}
 
+// FileContents holds the lines of a file read for the preview. Truncated is
+// set when the file goes on past the last line read, Err when it could not be
+// read at all.
+type FileContents struct {
+	Lines     []string
+	Truncated bool
+	Err       error
 }
 
-func ReadFileContents(fileName string) (string, error) {
+// ReadFileContents reads up to maxLines lines of fileName.
+func ReadFileContents(fileName string, maxLines int) FileContents {
 	file, err := os.Open(fileName)
 	if err != nil {
-		return "", err
+		return FileContents{Err: err}
 	}
 	defer file.Close()
 
-	var lines []string
+	var contents FileContents
 	scanner := bufio.NewScanner(file)
-	lineCount := 0
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-		lineCount++
-		if lineCount >= 200 {
+		if len(contents.Lines) >= maxLines {
+			contents.Truncated = true
 			break
 		}
+		contents.Lines = append(contents.Lines, scanner.Text())
 	}
 
 	if err := scanner.Err(); err != nil {
-		return "", err
+		return FileContents{Err: err}
 	}
 
-	return strings.Join(lines, "\n"), nil
+	return contents
+}
+
+// TargetPath returns dst, or the path inside dst when it is an existing
+// directory, so files can be copied or moved into a directory by naming it.
+func TargetPath(src, dst string) string {
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		return filepath.Join(dst, filepath.Base(src))
+	}
+	return dst
 }
 
 func CopyFile(src, dst string) error {
+	dst = TargetPath(src, dst)
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -75,13 +92,18 @@ func CopyFile(src, dst string) error {
 }
 
 func MoveFile(src, dst string) error {
-	return os.Rename(src, dst)
+	return os.Rename(src, TargetPath(src, dst))
 }
 
 func DeleteFile(fileName string) error {
 	return os.Remove(fileName)
 }
 
+// DeleteTree deletes path, and everything inside it when it is a directory.
+func DeleteTree(path string) error {
+	return os.RemoveAll(path)
+}
+
 func RenameFile(oldName, newName string) error {
 	return os.Rename(oldName, newName)
 }
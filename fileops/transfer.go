@@ -0,0 +1,259 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConflictAction says what to do when the target of a copy or move exists.
+type ConflictAction int
+
+const (
+	Overwrite ConflictAction = iota
+	Skip
+	// KeepBoth copies under a new name such as "notes (1).txt".
+	KeepBoth
+	Abort
+)
+
+// ErrAborted is returned by Transfer when the conflict handler aborted it.
+var ErrAborted = errors.New("transfer aborted")
+
+// rename is os.Rename, replaced in tests to make moves fall back to copying.
+var rename = os.Rename
+
+// TransferOptions configure Transfer.
+type TransferOptions struct {
+	// Move removes the sources once they have been copied.
+	Move bool
+	// Conflict decides what happens when a file already exists at the target.
+	// Existing directories are merged into instead. A nil Conflict overwrites.
+	Conflict func(src, dst string) ConflictAction
+	// Progress is called before every file and after every chunk that is
+	// copied, with the number of bytes done out of total.
+	Progress func(done, total int64, current string)
+}
+
+// Transfer copies or moves sources into the directory dstDir, descending into
+// directories. Moves are renames where possible and fall back to copying and
+// removing, for example across file systems.
+func Transfer(sources []string, dstDir string, opts TransferOptions) error {
+	t := &transfer{opts: opts}
+	for _, src := range sources {
+		size, err := treeSize(src)
+		if err != nil {
+			return err
+		}
+		t.total += size
+	}
+
+	for _, src := range sources {
+		dst := filepath.Join(dstDir, filepath.Base(src))
+		if same, err := samePath(src, dst); err != nil {
+			return err
+		} else if same {
+			return fmt.Errorf("'%s' is already in %s", filepath.Base(src), dstDir)
+		}
+		if isWithinDir(dst, src) {
+			return fmt.Errorf("cannot copy '%s' into itself", src)
+		}
+		if err := t.transfer(src, dst, opts.Move); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type transfer struct {
+	opts  TransferOptions
+	done  int64
+	total int64
+}
+
+func (t *transfer) transfer(src, dst string, move bool) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	isDir := info.IsDir()
+	target, err := os.Lstat(dst)
+	switch {
+	case err == nil && target.IsDir() && isDir:
+		// Merge into the existing directory below
+	case err == nil:
+		action := Overwrite
+		if t.opts.Conflict != nil {
+			action = t.opts.Conflict(src, dst)
+		}
+		switch action {
+		case Skip:
+			size, _ := treeSize(src)
+			t.advance(size, src)
+			return nil
+		case Abort:
+			return ErrAborted
+		case KeepBoth:
+			dst = uniqueName(dst)
+		case Overwrite:
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if move {
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			if err := rename(src, dst); err == nil {
+				size, _ := treeSize(dst)
+				t.advance(size, src)
+				return nil
+			}
+		}
+	}
+
+	if !isDir {
+		if err := t.copyEntry(src, dst, info); err != nil {
+			return err
+		}
+		if move {
+			return os.Remove(src)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := t.transfer(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), move); err != nil {
+			return err
+		}
+	}
+	if move {
+		// Skipped entries stay behind, and with them the directory
+		if remaining, err := os.ReadDir(src); err == nil && len(remaining) == 0 {
+			return os.Remove(src)
+		}
+	}
+	return nil
+}
+
+// copyEntry copies a file, or recreates a symlink, at dst.
+func (t *transfer) copyEntry(src, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	}
+	return t.copyFile(src, dst, info)
+}
+
+// copyFile copies the contents of src to dst. A partly written dst is removed
+// again when the copy fails.
+func (t *transfer) copyFile(src, dst string, info os.FileInfo) error {
+	t.advance(0, src)
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if err := t.copyContents(in, out, src); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+func (t *transfer) copyContents(in io.Reader, out io.Writer, src string) error {
+	buf := make([]byte, 256*1024)
+	for {
+		n, readErr := in.Read(buf)
+		if n > 0 {
+			if _, err := out.Write(buf[:n]); err != nil {
+				return err
+			}
+			t.advance(int64(n), src)
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func (t *transfer) advance(n int64, current string) {
+	t.done += n
+	if t.opts.Progress != nil {
+		t.opts.Progress(t.done, t.total, current)
+	}
+}
+
+// treeSize returns the number of bytes in path and everything below it.
+func treeSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// uniqueName returns path with " (n)" added before its extension, using the
+// first n for which nothing exists yet.
+func uniqueName(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+func samePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
+
+func isWithinDir(path, dir string) bool {
+	absPath, err1 := filepath.Abs(path)
+	absDir, err2 := filepath.Abs(dir)
+	return err1 == nil && err2 == nil && strings.HasPrefix(absPath, absDir+string(filepath.Separator))
+}
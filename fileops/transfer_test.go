@@ -0,0 +1,155 @@
+package fileops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files named by the keys of files below dir, with
+// the values as their contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkFile fails unless path holds contents.
+func checkFile(t *testing.T, path, contents string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != contents {
+		t.Errorf("%s contains %q, want %q", path, data, contents)
+	}
+}
+
+// checkMissing fails if path exists.
+func checkMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it gone (err = %v)", path, err)
+	}
+}
+
+func TestTransferMergesIntoExistingDirectory(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"docs/new.txt": "new", "docs/both.txt": "from src"})
+	writeFiles(t, dst, map[string]string{"docs/old.txt": "old", "docs/both.txt": "from dst"})
+
+	var conflicts []string
+	err := Transfer([]string{filepath.Join(src, "docs")}, dst, TransferOptions{
+		Conflict: func(_, dst string) ConflictAction {
+			conflicts = append(conflicts, filepath.Base(dst))
+			return Overwrite
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(dst, "docs", "new.txt"), "new")
+	checkFile(t, filepath.Join(dst, "docs", "old.txt"), "old")
+	checkFile(t, filepath.Join(dst, "docs", "both.txt"), "from src")
+	if len(conflicts) != 1 || conflicts[0] != "both.txt" {
+		t.Errorf("conflicts = %v, want only both.txt", conflicts)
+	}
+}
+
+func TestTransferKeepBoth(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"notes.txt": "new"})
+	writeFiles(t, dst, map[string]string{"notes.txt": "old", "notes (1).txt": "older"})
+
+	err := Transfer([]string{filepath.Join(src, "notes.txt")}, dst, TransferOptions{
+		Conflict: func(_, _ string) ConflictAction { return KeepBoth },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(dst, "notes.txt"), "old")
+	checkFile(t, filepath.Join(dst, "notes (1).txt"), "older")
+	checkFile(t, filepath.Join(dst, "notes (2).txt"), "new")
+	checkFile(t, filepath.Join(src, "notes.txt"), "new")
+}
+
+func TestTransferSkipLeavesSourceOnMove(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"dir/skipped.txt": "src", "dir/moved.txt": "moved"})
+	writeFiles(t, dst, map[string]string{"dir/skipped.txt": "dst"})
+
+	err := Transfer([]string{filepath.Join(src, "dir")}, dst, TransferOptions{
+		Move:     true,
+		Conflict: func(_, _ string) ConflictAction { return Skip },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(src, "dir", "skipped.txt"), "src")
+	checkFile(t, filepath.Join(dst, "dir", "skipped.txt"), "dst")
+	checkFile(t, filepath.Join(dst, "dir", "moved.txt"), "moved")
+	checkMissing(t, filepath.Join(src, "dir", "moved.txt"))
+}
+
+func TestTransferRejectsCopyIntoItself(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"dir/sub/file.txt": "x"})
+
+	err := Transfer([]string{filepath.Join(src, "dir")}, filepath.Join(src, "dir", "sub"), TransferOptions{})
+	if err == nil || !strings.Contains(err.Error(), "into itself") {
+		t.Fatalf("err = %v, want a copy into itself to be refused", err)
+	}
+	checkMissing(t, filepath.Join(src, "dir", "sub", "dir"))
+}
+
+func TestTransferMoveFallsBackToCopyAndRemove(t *testing.T) {
+	rename = func(_, _ string) error { return errors.New("cross-device link") }
+	defer func() { rename = os.Rename }()
+
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"dir/a.txt": "a", "dir/sub/b.txt": "bb"})
+
+	var done, total int64
+	err := Transfer([]string{filepath.Join(src, "dir")}, dst, TransferOptions{
+		Move:     true,
+		Progress: func(d, n int64, _ string) { done, total = d, n },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(dst, "dir", "a.txt"), "a")
+	checkFile(t, filepath.Join(dst, "dir", "sub", "b.txt"), "bb")
+	checkMissing(t, filepath.Join(src, "dir"))
+	if done != 3 || total != 3 {
+		t.Errorf("progress ended at %d of %d bytes, want 3 of 3", done, total)
+	}
+}
+
+func TestCopyFileRemovesPartialTarget(t *testing.T) {
+	dir := t.TempDir()
+	// Reading a directory fails once the copy has started
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+
+	if err := (&transfer{}).copyFile(src, dst, info); err == nil {
+		t.Fatal("copyFile succeeded, want a read error")
+	}
+	checkMissing(t, dst)
+}
@@ -0,0 +1,221 @@
+package frecency
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lds/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the database file, stored next to config.json.
+const FileName = "frecency.json"
+
+// DefaultMaxAge is the total rank above which all entries are aged.
+const DefaultMaxAge = 10000
+
+// Entry is a visited directory with its visit rank and the time of the last
+// visit in Unix seconds.
+type Entry struct {
+	Path       string  `json:"path"`
+	Rank       float64 `json:"rank"`
+	LastAccess int64   `json:"lastAccess"`
+}
+
+// DB is a small database of visited directories ranked by frecency, a mix of
+// how often and how recently a directory was visited. The file is re-read
+// before every change so that concurrent lds instances don't lose visits.
+type DB struct {
+	path    string
+	maxAge  float64
+	entries []Entry
+}
+
+// Open returns the database stored next to the config file. maxAge of 0 uses
+// DefaultMaxAge.
+func Open(maxAge float64) *DB {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &DB{path: config.DataFile(FileName), maxAge: maxAge}
+}
+
+func (db *DB) load() error {
+	db.entries = nil
+	data, err := os.ReadFile(db.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &db.entries); err != nil {
+		return fmt.Errorf("parsing %s: %w", db.path, err)
+	}
+	return nil
+}
+
+func (db *DB) save() error {
+	data, err := json.Marshal(db.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(db.path), 0755); err != nil {
+		return err
+	}
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, db.path)
+}
+
+// Add records a visit to dir.
+func (db *DB) Add(dir string) error {
+	return db.Import([]Entry{{Path: dir, Rank: 1, LastAccess: time.Now().Unix()}})
+}
+
+// Import merges entries into the database, adding up the ranks of directories
+// that are already known.
+func (db *DB) Import(entries []Entry) error {
+	if err := db.load(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		i := slices.IndexFunc(db.entries, func(e Entry) bool { return e.Path == entry.Path })
+		if i < 0 {
+			db.entries = append(db.entries, entry)
+			continue
+		}
+		db.entries[i].Rank += entry.Rank
+		db.entries[i].LastAccess = max(db.entries[i].LastAccess, entry.LastAccess)
+	}
+	db.age()
+	return db.save()
+}
+
+// age scales all ranks down once their total passes maxAge and forgets the
+// entries that drop below a single visit, so directories that are no longer
+// used eventually disappear.
+func (db *DB) age() {
+	total := 0.0
+	for _, entry := range db.entries {
+		total += entry.Rank
+	}
+	if total <= db.maxAge {
+		return
+	}
+	factor := 0.9 * db.maxAge / total
+	db.entries = slices.DeleteFunc(db.entries, func(e Entry) bool {
+		return e.Rank*factor < 1
+	})
+	for i := range db.entries {
+		db.entries[i].Rank *= factor
+	}
+}
+
+// score weights the rank of an entry by how long ago it was last visited.
+func score(entry Entry, now time.Time) float64 {
+	since := now.Sub(time.Unix(entry.LastAccess, 0))
+	switch {
+	case since < time.Hour:
+		return entry.Rank * 4
+	case since < 24*time.Hour:
+		return entry.Rank * 2
+	case since < 7*24*time.Hour:
+		return entry.Rank / 2
+	}
+	return entry.Rank / 4
+}
+
+// Query returns the existing directories matching keywords, best first. The
+// keywords have to appear in the path in order, and the last one has to match
+// the last path component, so "proj api" finds ~/work/projects/api.
+func (db *DB) Query(keywords []string) ([]string, error) {
+	if err := db.load(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var matches []Entry
+	for _, entry := range db.entries {
+		if matchesKeywords(entry.Path, keywords) {
+			if info, err := os.Stat(entry.Path); err == nil && info.IsDir() {
+				matches = append(matches, entry)
+			}
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Entry) int {
+		sa, sb := score(a, now), score(b, now)
+		switch {
+		case sa > sb:
+			return -1
+		case sa < sb:
+			return 1
+		}
+		return 0
+	})
+	paths := make([]string, len(matches))
+	for i, entry := range matches {
+		paths[i] = entry.Path
+	}
+	return paths, nil
+}
+
+func matchesKeywords(path string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	path = strings.ToLower(path)
+	rest := path
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(keyword)
+		i := strings.Index(rest, keyword)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(keyword):]
+	}
+	last := strings.ToLower(keywords[len(keywords)-1])
+	return strings.Contains(filepath.Base(path), last)
+}
+
+// ParseZoxide reads the output of `zoxide query --list --score`, one
+// "score path" pair per line. zoxide does not export access times, so the
+// imported entries count as visited now.
+func ParseZoxide(r io.Reader) ([]Entry, error) {
+	return parseScored(r, func(line string) (string, string, bool) {
+		return strings.Cut(strings.TrimSpace(line), " ")
+	})
+}
+
+// ParseAutojump reads an autojump.txt data file, one "weight<TAB>path" pair per
+// line.
+func ParseAutojump(r io.Reader) ([]Entry, error) {
+	return parseScored(r, func(line string) (string, string, bool) {
+		return strings.Cut(line, "\t")
+	})
+}
+
+func parseScored(r io.Reader, split func(line string) (string, string, bool)) ([]Entry, error) {
+	now := time.Now().Unix()
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rank, path, ok := split(scanner.Text())
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(rank), 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Path: strings.TrimSpace(path), Rank: value, LastAccess: now})
+	}
+	return entries, scanner.Err()
+}
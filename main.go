@@ -2,13 +2,19 @@ package main
 
 import (
 	"fmt"
+	"lds/cli"
 	"lds/config"
 	"lds/events"
+	"lds/fileops"
 	"lds/logging"
+	"lds/notifications"
 	"lds/ui"
 	"lds/utils"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,21 +24,77 @@ import (
 var wg sync.WaitGroup
 
 func main() {
+	opts, err := cli.Parse(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lds: %v\n%s", err, cli.Usage)
+		os.Exit(2)
+	}
+	if opts.Help {
+		fmt.Print(cli.Usage)
+		return
+	}
+	if opts.Command == "init" {
+		if len(opts.CommandArgs) != 1 {
+			fmt.Fprint(os.Stderr, "Usage: lds init bash|zsh|fish\n")
+			os.Exit(2)
+		}
+		script, err := cli.ShellInit(opts.CommandArgs[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lds: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Print(script)
+		return
+	}
+
 	cfg, err := ui.GetConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		return
 	}
+	if opts.Command == "import" {
+		if err := cli.Import(cfg, opts.CommandArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "lds: %v\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if opts.HelpKeys {
+		fmt.Println(strings.Join(events.HelpText(cfg), "\n"))
+		return
+	}
 	configPath, err := config.FindConfigFile()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error finding config: %v\n", err)
 		return
 	}
-	log.Printf("Config file found at: %s", configPath)
 	logging.SetupLogging(cfg.Logging.File)
+	log.Printf("Config file found at: %s", configPath)
 
-	reloadConfig := make(chan struct{})
-	go events.WatchConfigFile(configPath, reloadConfig)
+	if err := cli.ResolveBookmarks(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "lds: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.JSON || opts.NDJSON {
+		if err := cli.PrintJSON(os.Stdout, cfg, opts); err != nil {
+			os.Exit(2)
+		}
+		return
+	}
+	if opts.ShouldList() {
+		if err := cli.PrintListing(os.Stdout, cfg, opts); err != nil {
+			os.Exit(2)
+		}
+		return
+	}
+	if len(opts.Paths) == 1 {
+		if err := os.Chdir(opts.Paths[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error changing directory: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	lsColors := ui.LoadLSColors(cfg)
 
 	screen, err := tcell.NewScreen()
 	if err != nil {
@@ -43,34 +105,100 @@ func main() {
 		logging.LogErrorAndExit("Error initializing screen", err)
 	}
 	defer screen.Fini()
+	if cfg.Mouse.Enabled {
+		screen.EnableMouse()
+	}
+	// wake redraws the screen from other goroutines, as the main loop mostly
+	// waits for input
+	wake := func() {
+		screen.PostEvent(tcell.NewEventInterrupt(nil))
+	}
+
+	reloadConfig := make(chan struct{}, 1)
+	go events.WatchConfigFile(configPath, reloadConfig, wake)
 
-	var userInput []rune
 	cursorVisible := true
 	ticker := time.NewTicker(time.Duration(cfg.AutoSave.Interval) * time.Second)
 	defer ticker.Stop()
 
-	currentBox := 2 // 2 = search box by default
-	scrollPositions := []int{0, 0, 0, 0}
-	selectedIndices := []int{0, 0, 0, 0}
-
-	query := string(userInput)
-	directories, regularFiles, hiddenFiles, bestMatch := utils.ReadDirectoryAndUpdateBestMatch(screen, query)
+	state := events.NewState(cfg)
+	state.Picker = events.PickerOptions{
+		Enabled:   opts.Pick,
+		Multi:     opts.Multi,
+		DirsOnly:  opts.DirsOnly,
+		FilesOnly: opts.FilesOnly,
+	}
+	state.Notifications.Wake = wake
+	directories, regularFiles, hiddenFiles, _ := utils.ReadDirectoryAndUpdateBestMatch(screen, "", utils.DetailsFor(cfg))
+	// previews caches the listings shown in the parent and preview columns
+	previews := map[string][]config.FileInfo{}
+	// fileContents caches the file shown in the preview for as long as it
+	// stays selected, so that it is read again after changes made elsewhere
+	fileContents := map[string]fileops.FileContents{}
+	// previewPath is the entry the preview was last drawn for
+	previewPath := ""
+	// sortBy and sortReverse are the order the cached listings are in
+	sortBy, sortReverse := state.SortBy, state.SortReverse
 
 	for {
 		select {
 		case <-reloadConfig:
-			cfg, err = config.LoadConfig(configPath)
+			newCfg, err := config.LoadConfig(configPath)
 			if err != nil {
-				log.Println("Error reloading config:", err)
+				state.Notify(notifications.Error, "Error reloading config: %v", err)
 			} else {
-				log.Println("Config reloaded")
+				// A changed sort order applies to the active tab, others keep
+				// the order they were given
+				if newCfg.Sort != cfg.Sort {
+					state.SortBy, state.SortReverse = newCfg.Sort.By, newCfg.Sort.Reverse
+				}
+				cfg = newCfg
+				lsColors = ui.LoadLSColors(cfg)
+				state.LayoutTree = nil
+				// The filters may ask for details that were not read
+				state.Reload = true
+				if cfg.Mouse.Enabled {
+					screen.EnableMouse()
+				} else {
+					screen.DisableMouse()
+				}
+				state.Notify(notifications.Info, "Config reloaded")
 			}
 		case <-ticker.C:
 			cursorVisible = !cursorVisible
 		default:
+			if state.Reload {
+				directories, regularFiles, hiddenFiles, _ = utils.ReadDirectoryAndUpdateBestMatch(screen, "", utils.DetailsFor(cfg))
+				state.Tree.Refresh()
+				clear(previews)
+				clear(fileContents)
+				state.Reload = false
+			}
+			if state.SortBy != sortBy || state.SortReverse != sortReverse {
+				sortBy, sortReverse = state.SortBy, state.SortReverse
+				clear(previews)
+			}
+
 			screen.Clear()
 			width, height := screen.Size()
-			boxWidth, _, halfBoxHeight, increasedBoxHeight := ui.CalculateBoxDimensions(width, height)
+			if state.LayoutTree == nil || state.LayoutName != cfg.Layout {
+				tree := ui.FindLayout(cfg, cfg.Layout)
+				state.LayoutTree, state.LayoutName = &tree, cfg.Layout
+			}
+			area := ui.Rect{X: 0, Y: 0, Width: width, Height: height}
+			if len(state.Tabs) > 1 {
+				area.Y, area.Height = 1, height-1
+			}
+			// The last row is the status bar
+			area.Height--
+			if err := state.Clipboard.Sync(); err != nil {
+				log.Println("Error reading the clipboard:", err)
+			}
+			layout := ui.ComputeLayout(cfg.Layout, state.LayoutTree, area)
+			state.Layout = layout
+			if layout.Boxes[state.CurrentBox].Empty() {
+				state.CurrentBox = max(slices.IndexFunc(layout.Boxes[:], func(r ui.Rect) bool { return !r.Empty() }), 0)
+			}
 
 			textStyle := tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Text))
 			borderStyle := tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Border))
@@ -79,58 +207,339 @@ func main() {
 			labelStyle := tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Label))
 			valueStyle := tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Value)).Bold(true)
 			focusedStyle := tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Focused)).Bold(true)
+			toasts := state.Toasts(cfg)
+			// The newest notification is repeated in the status line, which
+			// otherwise shows the keys of a sequence being typed
+			message, messageStyle := state.PendingKeys(), valueStyle
+			if len(toasts) > 0 {
+				latest := toasts[len(toasts)-1]
+				message, messageStyle = latest.Text, ui.NotificationStyle(valueStyle, latest.Severity)
+			}
+			toastArea := ui.Rect{X: 0, Y: area.Y, Width: width, Height: height - 1 - area.Y}
 
-			ui.DrawBorder(screen, 0, 0, boxWidth-1, increasedBoxHeight-1, borderStyle)                                    // Directories
-			ui.DrawBorder(screen, boxWidth, 0, width-1, increasedBoxHeight-1, borderStyle)                                // Files
-			ui.DrawBorder(screen, 0, increasedBoxHeight, boxWidth-1, increasedBoxHeight+halfBoxHeight-1, borderStyle)     // Search
-			ui.DrawBorder(screen, boxWidth, increasedBoxHeight, width-1, increasedBoxHeight+halfBoxHeight-1, borderStyle) // File Info
+			if state.Commander {
+				entries, files, _ := utils.ApplyFileFilters(directories, regularFiles, hiddenFiles, cfg)
+				utils.SortFiles(entries, state.SortBy, state.SortReverse)
+				utils.SortFiles(files, state.SortBy, state.SortReverse)
+				entries = append(entries, files...)
+				drawCommander(screen, cfg, state, entries, previews, textStyle, highlightStyle, focusedStyle, borderStyle, lsColors, message, messageStyle)
+				ui.DrawToasts(screen, toastArea, toasts, textStyle)
+				screen.Show()
+				events.HandleCommanderInput(screen, cfg, state, entries)
+				if state.Quit {
+					quit(screen, opts, state)
+					return
+				}
+				continue
+			}
 
-			ui.DrawTitles(screen, 0, 0, width, height, "", textStyle)
+			for _, r := range layout.Boxes {
+				ui.DrawRect(screen, r, borderStyle)
+			}
+			ui.DrawRect(screen, layout.Parent, borderStyle)
+			ui.DrawRect(screen, layout.Preview, borderStyle)
 
-			inputStr := string(userInput)
-			filteredDirectories := utils.FilterFiles(directories, inputStr)
-			filteredFiles := utils.FilterFiles(append(regularFiles, hiddenFiles...), inputStr)
-			bestMatch = utils.FindBestMatch(filteredDirectories, filteredFiles, nil, inputStr)
+			inputStr := string(state.UserInput)
+			visibleDirectories, visibleFiles, filteredOut := utils.ApplyFileFilters(directories, regularFiles, hiddenFiles, cfg)
+			utils.SortFiles(visibleDirectories, state.SortBy, state.SortReverse)
+			utils.SortFiles(visibleFiles, state.SortBy, state.SortReverse)
+			filteredDirectories := utils.FilterFiles(visibleDirectories, inputStr)
+			filteredFiles := utils.FilterFiles(visibleFiles, inputStr)
+			if state.Picker.DirsOnly {
+				filteredFiles = nil
+			}
+			state.BestMatch = utils.FindBestMatch(filteredDirectories, filteredFiles, nil, inputStr)
+
+			var treePrefixes []string
+			if cfg.TreeView.Enabled {
+				entries := state.Tree.Flatten(filteredDirectories, cfg, state.SortBy, state.SortReverse)
+				filteredDirectories = make([]config.FileInfo, len(entries))
+				treePrefixes = make([]string, len(entries))
+				for i, entry := range entries {
+					filteredDirectories[i] = entry.File
+					treePrefixes[i] = entry.Prefix
+				}
+			}
 
-			ui.DrawBox(screen, 0, 0, boxWidth, increasedBoxHeight, filteredDirectories, selectedIndices[0], scrollPositions[0], textStyle, highlightStyle, currentBox == 0)
-			ui.DrawBox(screen, boxWidth, 0, width-boxWidth, increasedBoxHeight, filteredFiles, selectedIndices[1], scrollPositions[1], textStyle, highlightStyle, currentBox == 1)
+			if state.Preselect != "" {
+				for i, file := range filteredFiles {
+					if file.Name == state.Preselect {
+						state.CurrentBox = 1
+						state.SelectedIndices[1] = i
+						state.ScrollPositions[1] = max(i-(layout.Boxes[1].Height-4), 0)
+						break
+					}
+				}
+				state.Preselect = ""
+			}
 
-			if currentBox == 1 && len(filteredFiles) > 0 {
-				selectedFile := filteredFiles[selectedIndices[1]]
-				ui.DrawFileContents(screen, 0, 0, boxWidth, increasedBoxHeight, selectedFile, textStyle)
-			} else if currentBox == 0 && len(filteredDirectories) > 0 {
-				selectedFile := filteredDirectories[selectedIndices[0]]
-				ui.DisplayFileInfo(screen, boxWidth+3, increasedBoxHeight+1, width-1, selectedFile, labelStyle, valueStyle)
-			} else if currentBox == 2 && bestMatch != nil {
-				ui.DisplayFileInfo(screen, boxWidth+3, increasedBoxHeight+1, width-1, *bestMatch, labelStyle, valueStyle)
+			// Toggling filters or typing a query can shrink a list below the selection
+			for i, box := range [][]config.FileInfo{filteredDirectories, filteredFiles} {
+				if state.SelectedIndices[i] >= len(box) {
+					state.SelectedIndices[i] = max(len(box)-1, 0)
+					state.ScrollPositions[i] = min(state.ScrollPositions[i], state.SelectedIndices[i])
+				}
 			}
 
-			ui.DrawASCIIArt(screen)
+			var notes []string
+			if filteredOut > 0 {
+				notes = append(notes, fmt.Sprintf("%d filtered", filteredOut))
+			}
+			if len(state.Marked) > 0 {
+				notes = append(notes, fmt.Sprintf("%d marked", len(state.Marked)))
+			}
+			filesNote := ""
+			if len(notes) > 0 {
+				filesNote = "(" + strings.Join(notes, ", ") + ")"
+			}
+			ui.DrawTitles(screen, layout, filesNote, textStyle)
+			if len(state.Tabs) > 1 {
+				ui.DrawTabBar(screen, 0, width, state.TabTitles(), state.ActiveTab, textStyle, highlightStyle)
+			}
+			ui.DrawStatusBar(screen, height-1, width, statusText(cfg, state, filteredDirectories, filteredFiles, inputStr, filteredOut), message, labelStyle, messageStyle)
+
+			var columns []string
+			if cfg.ListView.Enabled {
+				columns = cfg.ListView.Columns
+				if len(columns) == 0 {
+					columns = ui.DefaultColumns
+				}
+			}
+
+			dirsBox, filesBox, searchBox, infoBox := layout.Boxes[0], layout.Boxes[1], layout.Boxes[2], layout.Boxes[3]
+			ui.DrawBox(screen, dirsBox.X, dirsBox.Y, dirsBox.Width, dirsBox.Height, filteredDirectories, state.SelectedIndices[0], state.ScrollPositions[0], textStyle, highlightStyle, state.CurrentBox == 0, columns, lsColors, state.Marked, treePrefixes)
+			ui.DrawBox(screen, filesBox.X, filesBox.Y, filesBox.Width, filesBox.Height, filteredFiles, state.SelectedIndices[1], state.ScrollPositions[1], textStyle, highlightStyle, state.CurrentBox == 1, columns, lsColors, state.Marked, nil)
+
+			var selected *config.FileInfo
+			if state.CurrentBox == 1 && len(filteredFiles) > 0 {
+				selected = &filteredFiles[state.SelectedIndices[1]]
+			} else if state.CurrentBox == 0 && len(filteredDirectories) > 0 {
+				selected = &filteredDirectories[state.SelectedIndices[0]]
+			} else if state.CurrentBox == 2 && state.BestMatch != nil {
+				selected = state.BestMatch
+			}
 
-			for i, r := range userInput {
-				screen.SetContent(1+i, increasedBoxHeight+1, r, nil, textStyle)
+			if selected == nil {
+				previewPath = ""
+				clear(fileContents)
+			} else if selected.Path != previewPath {
+				previewPath, state.PreviewScroll = selected.Path, 0
+				clear(fileContents)
 			}
-			if currentBox == 2 && cursorVisible {
-				screen.SetContent(1+len(userInput), increasedBoxHeight+1, '_', nil, blinkingStyle)
+			state.PreviewScroll = drawParentAndPreview(screen, layout, cfg, state.SortBy, state.SortReverse, previews, fileContents, selected, state.PreviewScroll, textStyle, highlightStyle, lsColors)
+			if selected != nil && state.CurrentBox == 1 && layout.Preview.Empty() {
+				// Without a preview column the file contents take the place of the Directories box
+				state.PreviewScroll = ui.DrawFileContents(screen, dirsBox.X, dirsBox.Y, dirsBox.Width, dirsBox.Height, readPreview(fileContents, selected.Path), state.PreviewScroll, textStyle)
+			} else if selected != nil {
+				ui.DisplayFileInfo(screen, infoBox.X+3, infoBox.Y+1, infoBox.Width-4, infoBox.Height-2, *selected, labelStyle, valueStyle)
 			}
 
-			switch currentBox {
-			case 0:
-				ui.DrawBorder(screen, 0, 0, boxWidth-1, increasedBoxHeight-1, focusedStyle)
-			case 1:
-				ui.DrawBorder(screen, boxWidth, 0, width-1, increasedBoxHeight-1, focusedStyle)
-			case 2:
-				ui.DrawBorder(screen, 0, increasedBoxHeight, boxWidth-1, increasedBoxHeight+halfBoxHeight-1, focusedStyle)
-			case 3:
-				ui.DrawBorder(screen, boxWidth, increasedBoxHeight, width-1, increasedBoxHeight+halfBoxHeight-1, focusedStyle)
+			ui.DrawASCIIArt(screen, infoBox)
+
+			if !searchBox.Empty() {
+				// A long input shows its end, where the typing happens, with room left for the cursor
+				input := state.UserInput
+				if visible := max(searchBox.Width-3, 0); len(input) > visible {
+					input = input[len(input)-visible:]
+				}
+				for i, r := range input {
+					screen.SetContent(searchBox.X+1+i, searchBox.Y+1, r, nil, textStyle)
+				}
+				if state.CurrentBox == 2 && cursorVisible {
+					screen.SetContent(searchBox.X+1+len(input), searchBox.Y+1, '_', nil, blinkingStyle)
+				}
 			}
 
+			ui.DrawRect(screen, layout.Boxes[state.CurrentBox], focusedStyle)
+			ui.DrawToasts(screen, toastArea, toasts, textStyle)
+
 			screen.Show()
-			currentBox, userInput, selectedIndices, scrollPositions, bestMatch =
-				events.HandleUserInput(screen, cfg, currentBox, userInput, [][]config.FileInfo{filteredDirectories, filteredFiles, nil}, selectedIndices, scrollPositions, bestMatch)
-			if currentBox == -1 {
+			events.HandleUserInput(screen, cfg, state, [][]config.FileInfo{filteredDirectories, filteredFiles, nil})
+			if state.Quit {
+				quit(screen, opts, state)
 				return
 			}
 		}
 	}
 }
+
+// drawParentAndPreview fills the parent column with the parent directory, the
+// current directory highlighted, and the preview column with the contents of
+// the selected directory or file, for the layouts that have them. The preview
+// starts at line scroll, and the scroll position actually used is returned.
+func drawParentAndPreview(screen tcell.Screen, layout ui.Layout, cfg *config.Config, sortBy string, sortReverse bool, previews map[string][]config.FileInfo, fileContents map[string]fileops.FileContents, selected *config.FileInfo, scroll int, textStyle, highlightStyle tcell.Style, lsColors ui.LSColors) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return scroll
+	}
+	listing := func(dir string) []config.FileInfo {
+		if entries, ok := previews[dir]; ok {
+			return entries
+		}
+		entries, err := utils.PreviewDirectory(dir, cfg, sortBy, sortReverse)
+		if err != nil {
+			log.Println("Error reading directory:", err)
+		}
+		previews[dir] = entries
+		return entries
+	}
+
+	if parent := filepath.Dir(cwd); parent != cwd && !layout.Parent.Empty() {
+		entries := listing(parent)
+		index := slices.IndexFunc(entries, func(file config.FileInfo) bool { return file.Path == cwd })
+		scroll := max(index-(layout.Parent.Height-3), 0)
+		ui.DrawBox(screen, layout.Parent.X, layout.Parent.Y, layout.Parent.Width, layout.Parent.Height, entries, index, scroll, textStyle, highlightStyle, true, nil, lsColors, nil, nil)
+	}
+
+	preview := layout.Preview
+	if selected == nil || preview.Empty() {
+		return scroll
+	}
+	if selected.FileType != "Directory" {
+		return ui.DrawFileContents(screen, preview.X, preview.Y, preview.Width, preview.Height, readPreview(fileContents, selected.Path), scroll, textStyle)
+	}
+	path, err := filepath.Abs(selected.Path)
+	if err != nil {
+		return scroll
+	}
+	entries := listing(path)
+	scroll = max(min(scroll, len(entries)-(preview.Height-2)), 0)
+	ui.DrawBox(screen, preview.X, preview.Y, preview.Width, preview.Height, entries, -1, scroll, textStyle, highlightStyle, false, nil, lsColors, nil, nil)
+	return scroll
+}
+
+// readPreview returns the contents of the file at path for the preview, reading
+// it on first use.
+func readPreview(fileContents map[string]fileops.FileContents, path string) fileops.FileContents {
+	contents, ok := fileContents[path]
+	if !ok {
+		contents = fileops.ReadFileContents(path, ui.PreviewLineLimit)
+		fileContents[path] = contents
+	}
+	return contents
+}
+
+// quit restores the terminal and hands the results of the session to the
+// caller: the last directory for the shell integration and the picked paths.
+func quit(screen tcell.Screen, opts *cli.Options, state *events.State) {
+	screen.Fini()
+	if err := cli.WriteLastDirectory(opts); err != nil {
+		log.Println("Error writing last directory:", err)
+	}
+	if opts.Pick {
+		if err := cli.WritePicked(os.Stdout, state.Picked, opts.Null); err != nil {
+			log.Println("Error writing picked paths:", err)
+		}
+		if len(state.Picked) == 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// drawCommander draws the two panes of commander mode, the active tab's
+// directory in entries and the other tab's read from disk, with a line of key
+// hints below them.
+func drawCommander(screen tcell.Screen, cfg *config.Config, state *events.State, entries []config.FileInfo, previews map[string][]config.FileInfo, textStyle, highlightStyle, focusedStyle, borderStyle tcell.Style, lsColors ui.LSColors, message string, messageStyle tcell.Style) {
+	width, height := screen.Size()
+	left, right := state.CommanderPanes()
+	panes := []ui.Rect{
+		{X: 0, Y: 0, Width: width / 2, Height: height - 1},
+		{X: width / 2, Y: 0, Width: width - width/2, Height: height - 1},
+	}
+	var columns []string
+	if cfg.ListView.Enabled {
+		columns = cfg.ListView.Columns
+		if len(columns) == 0 {
+			columns = ui.DefaultColumns
+		}
+	}
+
+	for i, tabIndex := range []int{left, right} {
+		pane := panes[i]
+		tab := state.Tabs[tabIndex]
+		if tabIndex == state.ActiveTab {
+			state.SelectedIndices[0] = min(state.SelectedIndices[0], max(len(entries)-1, 0))
+			state.Layout.Boxes[0] = pane
+			dir, _ := os.Getwd()
+			ui.DrawRect(screen, pane, focusedStyle)
+			ui.DrawRectTitle(screen, pane, dir, textStyle)
+			ui.DrawBox(screen, pane.X, pane.Y, pane.Width, pane.Height, entries, state.SelectedIndices[0], state.ScrollPositions[0], textStyle, highlightStyle, true, columns, lsColors, state.Marked, nil)
+			continue
+		}
+
+		other, ok := previews[tab.Dir]
+		if !ok {
+			var err error
+			if other, err = utils.PreviewDirectory(tab.Dir, cfg, tab.SortBy, tab.SortReverse); err != nil {
+				log.Println("Error reading directory:", err)
+			}
+			previews[tab.Dir] = other
+		}
+		selected, scroll := 0, 0
+		if len(tab.SelectedIndices) > 0 {
+			selected, scroll = tab.SelectedIndices[0], tab.ScrollPositions[0]
+		}
+		ui.DrawRect(screen, pane, borderStyle)
+		ui.DrawRectTitle(screen, pane, tab.Dir, textStyle)
+		ui.DrawBox(screen, pane.X, pane.Y, pane.Width, pane.Height, other, selected, scroll, textStyle, highlightStyle, false, columns, lsColors, state.Marked, nil)
+	}
+
+	// The hints name the first key of each action
+	var parts []string
+	if len(state.Marked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", len(state.Marked)))
+	}
+	for _, hint := range [][2]string{{"switchPane", "other pane"}, {"markNext", "mark"}, {"copyToPane", "copy"}, {"moveToPane", "move"}, {"execute", "open"}, {"paneParent", "up"}, {"leaveCommander", "leave"}} {
+		if keys := state.KeysFor(cfg, hint[0]); keys != "" {
+			key, _, _ := strings.Cut(keys, ", ")
+			parts = append(parts, key+" "+hint[1])
+		}
+	}
+	hints := strings.Join(parts, "  ")
+	ui.DrawStatusBar(screen, height-1, width, hints, message, tcell.StyleDefault, messageStyle)
+}
+
+// statusText describes the current directory for the status bar: its path,
+// the number of entries and which one is selected, and the search, filters
+// and sort order in effect.
+func statusText(cfg *config.Config, state *events.State, directories, files []config.FileInfo, query string, filteredOut int) string {
+	cwd, _ := os.Getwd()
+	parts := []string{cwd, fmt.Sprintf("%d dirs, %d files", len(directories), len(files))}
+	if box := state.CurrentBox; box <= 1 {
+		entries, kind := directories, "dir"
+		if box == 1 {
+			entries, kind = files, "file"
+		}
+		if len(entries) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d/%d", kind, state.SelectedIndices[box]+1, len(entries)))
+		}
+	}
+	if len(state.Marked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", len(state.Marked)))
+	}
+	if query != "" {
+		parts = append(parts, fmt.Sprintf("search %q", query))
+	}
+	var filters []string
+	if cfg.FileFilters.ShowHiddenFiles {
+		filters = append(filters, "hidden shown")
+	}
+	if filteredOut > 0 {
+		filters = append(filters, fmt.Sprintf("%d filtered", filteredOut))
+	}
+	if len(filters) > 0 {
+		parts = append(parts, strings.Join(filters, ", "))
+	}
+	sort := "by " + state.SortBy
+	if state.SortBy == "" {
+		sort = "by name"
+	}
+	if state.SortReverse {
+		sort += ", reversed"
+	}
+	parts = append(parts, sort)
+	if summary := state.Clipboard.Summary(); summary != "" {
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, " │ ")
+}
@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"time"
+)
+
+// Severity says how important a notification is, which decides its color and
+// whether it goes away by itself.
+type Severity int
+
+const (
+	Info Severity = iota
+	Success
+	Warning
+	// Error notifications stay on screen until they are dismissed.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "info"
+}
+
+// maxHistory is the number of notifications the history keeps.
+const maxHistory = 200
+
+// Notification is a message about the result of an operation.
+type Notification struct {
+	Text     string
+	Severity Severity
+	Time     time.Time
+	// Dismissed is set once the notification was closed, or has expired.
+	Dismissed bool
+}
+
+// Queue holds the notifications that have been posted, newest last.
+type Queue struct {
+	history []Notification
+	// Wake is called when a notification expires, so that the screen can
+	// be redrawn without it. It is called from another goroutine.
+	Wake  func()
+	timer *time.Timer
+}
+
+// Post adds a notification.
+func (q *Queue) Post(severity Severity, text string) {
+	q.history = append(q.history, Notification{Text: text, Severity: severity, Time: time.Now()})
+	if len(q.history) > maxHistory {
+		q.history = q.history[len(q.history)-maxHistory:]
+	}
+}
+
+// Visible returns the notifications to show, oldest first: those younger
+// than duration, and errors until they are dismissed. At most limit are
+// returned. When one of them is going to expire Wake is scheduled for then.
+func (q *Queue) Visible(duration time.Duration, limit int) []Notification {
+	var visible []Notification
+	var next time.Duration
+	for i := range q.history {
+		n := &q.history[i]
+		if n.Dismissed {
+			continue
+		}
+		if n.Severity != Error {
+			remaining := duration - time.Since(n.Time)
+			if remaining <= 0 {
+				n.Dismissed = true
+				continue
+			}
+			if next == 0 || remaining < next {
+				next = remaining
+			}
+		}
+		visible = append(visible, *n)
+	}
+	if len(visible) > limit {
+		visible = visible[len(visible)-limit:]
+	}
+
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	if next > 0 && q.Wake != nil {
+		q.timer = time.AfterFunc(next, q.Wake)
+	}
+	return visible
+}
+
+// Dismiss closes every notification, errors included.
+func (q *Queue) Dismiss() {
+	for i := range q.history {
+		q.history[i].Dismissed = true
+	}
+}
+
+// History returns every notification kept, newest first.
+func (q *Queue) History() []Notification {
+	history := make([]Notification, len(q.history))
+	for i, n := range q.history {
+		history[len(history)-1-i] = n
+	}
+	return history
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"lds/config"
+	"strings"
+	"time"
+)
+
+// DefaultColumns mirrors the column order of `ls -l`.
+var DefaultColumns = []string{"permissions", "links", "owner", "group", "size", "mtime", "name"}
+
+// columnDropOrder lists the columns that are given up first when a row does not
+// fit the box. The name column is never dropped.
+var columnDropOrder = []string{"links", "group", "git", "owner", "mtime", "permissions", "size"}
+
+const (
+	minNameWidth   = 8
+	maxColumnWidth = 16
+)
+
+// FormatColumn renders a single list view column for a file.
+func FormatColumn(file config.FileInfo, column string) string {
+	switch column {
+	case "permissions":
+		return file.Permissions
+	case "links":
+		return fmt.Sprint(file.HardLinksCount)
+	case "owner":
+		owner, _, _ := strings.Cut(file.Owner, ":")
+		return owner
+	case "group":
+		_, group, _ := strings.Cut(file.Owner, ":")
+		return group
+	case "size":
+		return formatFileSize(file.Size)
+	case "mtime":
+		return formatModTime(file.ModTime)
+	case "git":
+		return formatGitStatus(file.GitRepoStatus)
+	case "name":
+		return file.Name
+	}
+	return ""
+}
+
+// ColumnWidths returns the width of every column so that the rows line up. The
+// name column is reported as 0 since it takes whatever space is left.
+func ColumnWidths(files []config.FileInfo, columns []string) []int {
+	widths := make([]int, len(columns))
+	for _, file := range files {
+		for i, column := range columns {
+			if column == "name" {
+				continue
+			}
+			if w := len([]rune(FormatColumn(file, column))); w > widths[i] {
+				widths[i] = min(w, maxColumnWidth)
+			}
+		}
+	}
+	return widths
+}
+
+// FitColumns drops the least important columns until a row fits into width.
+func FitColumns(columns []string, widths []int, width int) ([]string, []int) {
+	for _, drop := range columnDropOrder {
+		if rowWidth(columns, widths)+minNameWidth <= width {
+			break
+		}
+		for i, column := range columns {
+			if column == drop {
+				columns = append(columns[:i:i], columns[i+1:]...)
+				widths = append(widths[:i:i], widths[i+1:]...)
+				break
+			}
+		}
+	}
+	return columns, widths
+}
+
+// FormatRow lays out the columns of a file into cells padded to their widths.
+// The name cell gets the space left over in width and is truncated to fit.
+func FormatRow(file config.FileInfo, columns []string, widths []int, width int) []string {
+	nameWidth := width - rowWidth(columns, widths)
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		text := FormatColumn(file, column)
+		switch column {
+		case "name":
+			if len([]rune(text)) > nameWidth {
+				text = truncateString(text, nameWidth-3) + "..."
+			}
+		case "size", "links":
+			text = fmt.Sprintf("%*s", widths[i], text)
+		default:
+			if len([]rune(text)) > widths[i] {
+				text = truncateString(text, widths[i]-1) + "~"
+			}
+			text = fmt.Sprintf("%-*s", widths[i], text)
+		}
+		cells[i] = text
+	}
+	return cells
+}
+
+func rowWidth(columns []string, widths []int) int {
+	total := 0
+	for i, column := range columns {
+		if column != "name" {
+			total += widths[i] + 1
+		}
+	}
+	return total
+}
+
+func formatModTime(modTime time.Time) string {
+	if modTime.IsZero() {
+		return ""
+	}
+	if time.Since(modTime) > 180*24*time.Hour || modTime.After(time.Now()) {
+		return modTime.Format("Jan _2  2006")
+	}
+	return modTime.Format("Jan _2 15:04")
+}
+
+func formatGitStatus(status string) string {
+	switch status {
+	case "Modified":
+		return "M"
+	case "Git repository":
+		return "G"
+	}
+	return "-"
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxDialogPaths is the number of paths a dialog lists before summing up the
+// rest.
+const maxDialogPaths = 8
+
+// Choice is an answer to a Dialog, picked with its key.
+type Choice struct {
+	Key   rune
+	Label string
+}
+
+// Dialog is a modal question about the paths it lists, such as the files
+// about to be deleted.
+type Dialog struct {
+	Title   string
+	Message string
+	Paths   []string
+	Choices []Choice
+	// Prompt asks for text to be typed instead of offering choices. Input is
+	// the text typed so far.
+	Prompt string
+	Input  string
+}
+
+// YesNo are the choices of a yes or no question.
+var YesNo = []Choice{{'y', "Yes"}, {'n', "No"}}
+
+// DrawDialog draws d in the middle of the screen, on top of what is there,
+// with the choice at index selected highlighted.
+func DrawDialog(screen tcell.Screen, d Dialog, selected int, style, borderStyle tcell.Style) {
+	width, height := screen.Size()
+	dialogWidth := min(width-4, 76)
+	inner := dialogWidth - 4
+
+	lines := wrapText(d.Message, inner, max(height/3, 1))
+	if len(d.Paths) > 0 {
+		lines = append(lines, "")
+		for i, path := range d.Paths {
+			if i == maxDialogPaths-1 && len(d.Paths) > maxDialogPaths {
+				lines = append(lines, fmt.Sprintf("  and %d more", len(d.Paths)-i))
+				break
+			}
+			lines = append(lines, "  "+path)
+		}
+	}
+	lines = append(lines, "")
+
+	// The choices are wrapped onto as many rows as they need, at the bottom
+	var rows [][]int
+	rowWidth := inner
+	for i, choice := range d.Choices {
+		labelWidth := len([]rune(choiceLabel(choice))) + 1
+		if rowWidth+labelWidth > inner+1 {
+			rows = append(rows, nil)
+			rowWidth = 0
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], i)
+		rowWidth += labelWidth
+	}
+	if len(rows) == 0 {
+		rows = [][]int{nil}
+	}
+
+	dialogHeight := min(len(lines)+2+len(rows), height)
+	x1 := (width - dialogWidth) / 2
+	y1 := (height - dialogHeight) / 2
+	x2, y2 := x1+dialogWidth-1, y1+dialogHeight-1
+	clearArea(screen, x1, y1, x2, y2)
+	DrawBorder(screen, x1, y1, x2, y2, borderStyle)
+	displayText(screen, x1+1, y1, " "+d.Title+" ", style.Bold(true), dialogWidth-2)
+	top := y2 - len(rows)
+	for i, line := range lines {
+		if y1+1+i >= top {
+			break
+		}
+		displayText(screen, x1+2, y1+1+i, line, style, inner)
+	}
+
+	// The last rows hold the prompt or the choices
+	if d.Prompt != "" {
+		y := y2 - 1
+		x := x1 + 2
+		x += displayText(screen, x, y, d.Prompt, style, inner)
+		x += displayText(screen, x, y, d.Input, style.Bold(true), x2-1-x)
+		displayText(screen, x, y, "_", style.Blink(true), x2-1-x)
+		return
+	}
+	for row, choices := range rows {
+		x := x1 + 2
+		for _, i := range choices {
+			choiceStyle := style
+			if i == selected {
+				choiceStyle = style.Reverse(true)
+			}
+			x += displayText(screen, x, top+row, choiceLabel(d.Choices[i]), choiceStyle, x2-1-x) + 1
+		}
+	}
+}
+
+func choiceLabel(choice Choice) string {
+	return fmt.Sprintf(" [%c] %s ", choice.Key, choice.Label)
+}
@@ -0,0 +1,346 @@
+package ui
+
+import (
+	"fmt"
+	"lds/config"
+	"log"
+	"maps"
+	"slices"
+)
+
+// Rect is the area a box takes up on screen, borders included.
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// Empty reports whether r has no room for a bordered box.
+func (r Rect) Empty() bool {
+	return r.Width < 3 || r.Height < 3
+}
+
+// Contains reports whether the cell at x, y lies within r.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Layout says where every box is drawn. Boxes is indexed like Titles. Boxes
+// the layout leaves out, and Parent and Preview when the layout has no such
+// columns, are empty.
+type Layout struct {
+	Name    string
+	Boxes   [4]Rect
+	Parent  Rect
+	Preview Rect
+	Splits  []Split
+}
+
+// Split is the border between two neighbouring children of a split node,
+// which can be dragged to resize them.
+type Split struct {
+	// Node is the split node, and the border lies between its children at
+	// Index and Index+1.
+	Node  *config.LayoutNode
+	Index int
+	// Columns is set for a vertical border between columns.
+	Columns bool
+	// Start is where the first of the two children begins and Length is the
+	// size of both together, along the direction of the split.
+	Start, Length int
+	// Pos is where the second child begins, and Area the node's own area.
+	Pos  int
+	Area Rect
+}
+
+// SplitAt returns the split whose border runs through the cell at x, y. The
+// border is two cells wide since both boxes draw their own edge.
+func (l Layout) SplitAt(x, y int) (Split, bool) {
+	for _, split := range l.Splits {
+		along, across := y, x
+		lo, hi := split.Area.X, split.Area.X+split.Area.Width
+		if split.Columns {
+			along, across = x, y
+			lo, hi = split.Area.Y, split.Area.Y+split.Area.Height
+		}
+		if (along == split.Pos || along == split.Pos-1) && across >= lo && across < hi {
+			return split, true
+		}
+	}
+	return Split{}, false
+}
+
+// Drag moves the border so that the second child starts at pos, keeping both
+// children at least three cells big.
+func (s Split) Drag(pos int) {
+	first, second := &s.Node.Children[s.Index], &s.Node.Children[s.Index+1]
+	if s.Length < 6 {
+		return
+	}
+	size := max(min(pos-s.Start, s.Length-3), 3)
+	switch {
+	case first.Size > 0 && second.Size > 0:
+		first.Size, second.Size = size, s.Length-size
+	case first.Size > 0:
+		first.Size = size
+	case second.Size > 0:
+		second.Size = s.Length - size
+	default:
+		total := weightOf(*first) + weightOf(*second)
+		first.Weight = total * float64(size) / float64(s.Length)
+		second.Weight = total - first.Weight
+	}
+}
+
+// BoxAt returns the index of the box that contains the cell at x, y, or -1
+// when the cell is not inside any of them.
+func (l Layout) BoxAt(x, y int) int {
+	for i, r := range l.Boxes {
+		if !r.Empty() && r.Contains(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+const (
+	LayoutGrid   = "grid"
+	LayoutMiller = "miller"
+)
+
+// boxIndices maps the box names used in layout definitions to their index in
+// Layout.Boxes. Parent and preview are not focusable boxes and have their own
+// fields.
+var boxIndices = map[string]int{"directories": 0, "files": 1, "search": 2, "info": 3}
+
+// builtinLayouts are always available. The grid is the original 2x2 layout and
+// miller a ranger style one with the parent directory on the left and a
+// preview of the selection on the right.
+var builtinLayouts = map[string]config.LayoutNode{
+	LayoutGrid: {Split: "rows", Children: []config.LayoutNode{
+		{Split: "columns", Weight: 3, Children: []config.LayoutNode{{Box: "directories"}, {Box: "files"}}},
+		{Split: "columns", Weight: 1, MinSize: 9, Children: []config.LayoutNode{{Box: "search"}, {Box: "info"}}},
+	}},
+	LayoutMiller: {Split: "rows", Children: []config.LayoutNode{
+		{Split: "columns", Children: []config.LayoutNode{
+			{Box: "parent", Weight: 1},
+			{Split: "rows", Weight: 3, Children: []config.LayoutNode{{Box: "directories"}, {Box: "files"}}},
+			{Split: "rows", Weight: 4, Children: []config.LayoutNode{{Box: "preview"}, {Box: "info", Size: 9}}},
+		}},
+		{Box: "search", Size: 3},
+	}},
+}
+
+// LayoutNames lists the built-in layouts followed by the ones defined in the
+// config, in the order Alt+v cycles through them.
+func LayoutNames(cfg *config.Config) []string {
+	names := []string{LayoutGrid, LayoutMiller}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Layouts)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// NextLayout returns the layout that follows name. Unknown names count as the
+// grid, which is what FindLayout falls back to.
+func NextLayout(cfg *config.Config, name string) string {
+	names := LayoutNames(cfg)
+	i := max(slices.Index(names, name), 0)
+	return names[(i+1)%len(names)]
+}
+
+// FindLayout returns a copy of the named layout, which can be resized without
+// touching the config. Layouts from the config take precedence over the
+// built-in ones, and an unknown or invalid layout falls back to the grid.
+func FindLayout(cfg *config.Config, name string) config.LayoutNode {
+	node, ok := cfg.Layouts[name]
+	if !ok {
+		node, ok = builtinLayouts[name]
+	}
+	if !ok {
+		if name != "" {
+			log.Printf("Unknown layout %q, using %s", name, LayoutGrid)
+		}
+		node = builtinLayouts[LayoutGrid]
+	} else if err := validateLayout(node, map[string]bool{}); err != nil {
+		log.Printf("Invalid layout %q: %v, using %s", name, err, LayoutGrid)
+		node = builtinLayouts[LayoutGrid]
+	}
+	return cloneLayout(node)
+}
+
+func validateLayout(node config.LayoutNode, seen map[string]bool) error {
+	if node.Box != "" {
+		if _, ok := boxIndices[node.Box]; !ok && node.Box != "parent" && node.Box != "preview" {
+			return fmt.Errorf("unknown box %q", node.Box)
+		}
+		if seen[node.Box] {
+			return fmt.Errorf("box %q is used twice", node.Box)
+		}
+		seen[node.Box] = true
+		return nil
+	}
+	if node.Split != "rows" && node.Split != "columns" {
+		return fmt.Errorf("split must be rows or columns, not %q", node.Split)
+	}
+	if len(node.Children) == 0 {
+		return fmt.Errorf("%s split without children", node.Split)
+	}
+	for _, child := range node.Children {
+		if err := validateLayout(child, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cloneLayout(node config.LayoutNode) config.LayoutNode {
+	node.Children = slices.Clone(node.Children)
+	for i, child := range node.Children {
+		node.Children[i] = cloneLayout(child)
+	}
+	return node
+}
+
+// ComputeLayout places the boxes of root within area. The splits of the
+// layout point into root, so dragging them resizes root.
+func ComputeLayout(name string, root *config.LayoutNode, area Rect) Layout {
+	layout := Layout{Name: name}
+	placeNode(&layout, root, area)
+	return layout
+}
+
+func placeNode(layout *Layout, node *config.LayoutNode, r Rect) {
+	switch node.Box {
+	case "":
+	case "parent":
+		layout.Parent = r
+		return
+	case "preview":
+		layout.Preview = r
+		return
+	default:
+		layout.Boxes[boxIndices[node.Box]] = r
+		return
+	}
+
+	length, pos := r.Height, r.Y
+	if node.Split == "columns" {
+		length, pos = r.Width, r.X
+	}
+	end := pos + length
+	sizes := splitSizes(node.Children, length)
+	for i, size := range sizes {
+		size = max(min(size, end-pos), 0)
+		child := Rect{r.X, pos, r.Width, size}
+		if node.Split == "columns" {
+			child = Rect{pos, r.Y, size, r.Height}
+		}
+		placeNode(layout, &node.Children[i], child)
+		if i+1 < len(sizes) {
+			layout.Splits = append(layout.Splits, Split{
+				Node:    node,
+				Index:   i,
+				Columns: node.Split == "columns",
+				Start:   pos,
+				Length:  size + sizes[i+1],
+				Pos:     pos + size,
+				Area:    r,
+			})
+		}
+		pos += size
+	}
+}
+
+// splitSizes divides length between children. Fixed sizes are handed out
+// first and the rest is shared by weight, after which children below their
+// minimum size take cells from the siblings that have the most to spare.
+func splitSizes(children []config.LayoutNode, length int) []int {
+	sizes := make([]int, len(children))
+	rest := length
+	totalWeight := 0.0
+	lastFlexible := -1
+	for i, child := range children {
+		if child.Size > 0 {
+			sizes[i] = child.Size
+			rest -= child.Size
+		} else {
+			totalWeight += weightOf(child)
+			lastFlexible = i
+		}
+	}
+	rest = max(rest, 0)
+	shared := 0
+	for i, child := range children {
+		if child.Size == 0 {
+			sizes[i] = int(float64(rest) * weightOf(child) / totalWeight)
+			shared += sizes[i]
+		}
+	}
+	if lastFlexible >= 0 {
+		sizes[lastFlexible] += rest - shared
+	}
+
+	for i, child := range children {
+		for sizes[i] < child.MinSize {
+			donor, spare := -1, 0
+			for j, sibling := range children {
+				if j != i && sizes[j]-max(sibling.MinSize, 3) > spare {
+					donor, spare = j, sizes[j]-max(sibling.MinSize, 3)
+				}
+			}
+			if donor < 0 {
+				break
+			}
+			take := min(child.MinSize-sizes[i], spare)
+			sizes[i] += take
+			sizes[donor] -= take
+		}
+	}
+	return sizes
+}
+
+func weightOf(node config.LayoutNode) float64 {
+	if node.Weight <= 0 {
+		return 1
+	}
+	return node.Weight
+}
+
+// ResizeBox grows or shrinks the box at index along the nearest split in the
+// given direction, "rows" to change its height and "columns" its width. It
+// reports whether the layout has such a split.
+func ResizeBox(root *config.LayoutNode, index int, split string, grow bool) bool {
+	path := pathToBox(root, index)
+	for i := len(path) - 2; i >= 0; i-- {
+		if path[i].Split != split {
+			continue
+		}
+		child := path[i+1]
+		switch {
+		case child.Size > 0 && grow:
+			child.Size++
+		case child.Size > 0:
+			child.Size = max(child.Size-1, child.MinSize, 3)
+		case grow:
+			child.Weight = weightOf(*child) * 1.25
+		default:
+			child.Weight = weightOf(*child) / 1.25
+		}
+		return true
+	}
+	return false
+}
+
+// pathToBox returns the nodes from root down to the box at index.
+func pathToBox(node *config.LayoutNode, index int) []*config.LayoutNode {
+	if i, ok := boxIndices[node.Box]; ok && i == index {
+		return []*config.LayoutNode{node}
+	}
+	for i := range node.Children {
+		if path := pathToBox(&node.Children[i], index); path != nil {
+			return append([]*config.LayoutNode{node}, path...)
+		}
+	}
+	return nil
+}
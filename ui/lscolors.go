@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"lds/config"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// LSColors maps dircolors keys such as "di", "ex" or "*.go" to their SGR codes.
+type LSColors map[string]string
+
+// defaultLSColors are the GNU ls defaults, used when LS_COLORS is not set.
+const defaultLSColors = "di=01;34:ln=01;36:so=01;35:pi=40;33:ex=01;32:bd=40;33;01:cd=40;33;01:or=40;31;01"
+
+// LoadLSColors builds the color table from the GNU defaults, the LS_COLORS
+// environment variable and the lsColors section of the config, in that order.
+// It returns nil when lsColors is disabled.
+func LoadLSColors(cfg *config.Config) LSColors {
+	if !cfg.LSColors.Enabled {
+		return nil
+	}
+	colors := ParseLSColors(defaultLSColors)
+	for key, code := range ParseLSColors(os.Getenv("LS_COLORS")) {
+		colors[key] = code
+	}
+	for key, code := range cfg.LSColors.Colors {
+		colors[key] = code
+	}
+	return colors
+}
+
+// ParseLSColors parses the colon separated key=code format used by LS_COLORS.
+func ParseLSColors(s string) LSColors {
+	colors := LSColors{}
+	for _, entry := range strings.Split(s, ":") {
+		key, code, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		colors[key] = code
+	}
+	return colors
+}
+
+// CodeFor returns the SGR code for a file, following the precedence GNU ls uses:
+// the file type first, then the executable bit, then the longest matching
+// extension pattern.
+func (c LSColors) CodeFor(file config.FileInfo) string {
+	if c == nil {
+		return ""
+	}
+	switch {
+	case file.IsBrokenSymlink && c["or"] != "":
+		return c["or"]
+	case file.IsSymlink:
+		return c["ln"]
+	case file.FileType == "Directory":
+		return c["di"]
+	case file.FileType == "Named Pipe":
+		return c["pi"]
+	case file.FileType == "Socket":
+		return c["so"]
+	case file.FileType == "Block Device":
+		return c["bd"]
+	case file.FileType == "Character Device":
+		return c["cd"]
+	case file.IsExecutable && c["ex"] != "":
+		return c["ex"]
+	}
+
+	name := strings.ToLower(file.Name)
+	code, matched := "", 0
+	for key, value := range c {
+		pattern := strings.ToLower(strings.TrimPrefix(key, "*"))
+		if strings.HasPrefix(key, "*") && len(pattern) > matched && strings.HasSuffix(name, pattern) {
+			code, matched = value, len(pattern)
+		}
+	}
+	if code != "" {
+		return code
+	}
+	return c["fi"]
+}
+
+// StyleFor returns base with the file's color applied on top of it.
+func (c LSColors) StyleFor(file config.FileInfo, base tcell.Style) tcell.Style {
+	return StyleFromSGR(c.CodeFor(file), base)
+}
+
+// StyleFromSGR applies an SGR code such as "01;34" or "38;5;208" to base.
+func StyleFromSGR(code string, base tcell.Style) tcell.Style {
+	if code == "" {
+		return base
+	}
+	style := base
+	params := strings.Split(code, ";")
+	for i := 0; i < len(params); i++ {
+		n, err := strconv.Atoi(params[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			style = base
+		case n == 1:
+			style = style.Bold(true)
+		case n == 2:
+			style = style.Dim(true)
+		case n == 3:
+			style = style.Italic(true)
+		case n == 4:
+			style = style.Underline(true)
+		case n == 5:
+			style = style.Blink(true)
+		case n == 7:
+			style = style.Reverse(true)
+		case n >= 30 && n <= 37:
+			style = style.Foreground(tcell.PaletteColor(n - 30))
+		case n >= 40 && n <= 47:
+			style = style.Background(tcell.PaletteColor(n - 40))
+		case n >= 90 && n <= 97:
+			style = style.Foreground(tcell.PaletteColor(n - 90 + 8))
+		case n >= 100 && n <= 107:
+			style = style.Background(tcell.PaletteColor(n - 100 + 8))
+		case n == 39:
+			fg, _, _ := base.Decompose()
+			style = style.Foreground(fg)
+		case n == 49:
+			_, bg, _ := base.Decompose()
+			style = style.Background(bg)
+		case n == 38 || n == 48:
+			color, used := extendedColor(params[i+1:])
+			i += used
+			if n == 38 {
+				style = style.Foreground(color)
+			} else {
+				style = style.Background(color)
+			}
+		}
+	}
+	return style
+}
+
+// extendedColor parses the arguments of a 38/48 sequence, either "5;n" for the
+// 256 color palette or "2;r;g;b" for true color. It returns the color and the
+// number of parameters it consumed.
+func extendedColor(params []string) (tcell.Color, int) {
+	if len(params) == 0 {
+		return tcell.ColorDefault, 0
+	}
+	values := make([]int32, len(params))
+	for i, p := range params {
+		v, _ := strconv.Atoi(p)
+		values[i] = int32(v)
+	}
+	switch {
+	case values[0] == 5 && len(values) >= 2:
+		return tcell.PaletteColor(int(values[1])), 2
+	case values[0] == 2 && len(values) >= 4:
+		return tcell.NewRGBColor(values[1], values[2], values[3]), 4
+	}
+	return tcell.ColorDefault, len(values)
+}
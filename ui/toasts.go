@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"lds/notifications"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// toastColors are the border colors of the notifications by severity.
+var toastColors = map[notifications.Severity]tcell.Color{
+	notifications.Info:    tcell.ColorSteelBlue,
+	notifications.Success: tcell.ColorGreen,
+	notifications.Warning: tcell.ColorYellow,
+	notifications.Error:   tcell.ColorRed,
+}
+
+// NotificationStyle returns style in the color of a notification of the given
+// severity.
+func NotificationStyle(style tcell.Style, severity notifications.Severity) tcell.Style {
+	return style.Foreground(toastColors[severity]).Bold(true)
+}
+
+// maxToastLines is the number of lines a notification is wrapped to at most.
+const maxToastLines = 3
+
+// DrawToasts draws notifications as boxes stacked up from the bottom right
+// corner of area, the newest at the bottom. Those that do not fit are left
+// out.
+func DrawToasts(screen tcell.Screen, area Rect, toasts []notifications.Notification, style tcell.Style) {
+	maxWidth := min(max(area.Width/2, 40), area.Width)
+	bottom := area.Y + area.Height
+	for i := len(toasts) - 1; i >= 0; i-- {
+		toast := toasts[i]
+		title := toast.Severity.String()
+		lines := wrapText(toast.Text, maxWidth-4, maxToastLines)
+		width := len(title) + 2
+		for _, line := range lines {
+			width = max(width, len([]rune(line)))
+		}
+		width += 4
+		height := len(lines) + 2
+		if bottom-height < area.Y || width > area.Width {
+			break
+		}
+
+		r := Rect{X: area.X + area.Width - width, Y: bottom - height, Width: width, Height: height}
+		clearArea(screen, r.X, r.Y, r.X+r.Width-1, r.Y+r.Height-1)
+		borderStyle := NotificationStyle(style, toast.Severity)
+		DrawRect(screen, r, borderStyle)
+		DrawRectTitle(screen, r, " "+title+" ", borderStyle)
+		for j, line := range lines {
+			displayText(screen, r.X+2, r.Y+1+j, line, style, width-4)
+		}
+		bottom -= height
+	}
+}
+
+// wrapText breaks text into lines of at most width characters at spaces, or
+// anywhere in words that are too long. Lines past maxLines are left out, the
+// last line then ending in "…".
+func wrapText(text string, width, maxLines int) []string {
+	if width <= 0 || maxLines <= 0 {
+		return nil
+	}
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:width]))
+			word = string([]rune(word)[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = truncateString(lines[maxLines-1], width-1) + "…"
+	}
+	return lines
+}
@@ -5,16 +5,14 @@ import (
 	"lds/config"
 	"lds/fileops"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
 )
 
-var (
-	Titles             = []string{"Directories", "Files", "Search", "File Info"}
-	IncreasedBoxHeight int
-	HalfBoxHeight      int
-)
+var Titles = []string{"Directories", "Files", "Search", "File Info"}
 
 func GetConfig() (*config.Config, error) {
 	configPath, err := config.FindConfigFile()
@@ -54,52 +52,162 @@ func DrawBorder(screen tcell.Screen, x1, y1, x2, y2 int, style tcell.Style) {
 	screen.SetContent(x2, y2, tcell.RuneLRCorner, nil, style)
 }
 
-func CalculateBoxDimensions(width, height int) (int, int, int, int) {
-	boxWidth := width / 2
-	boxHeight := height / 2
-	HalfBoxHeight = boxHeight / 2
-	IncreasedBoxHeight = boxHeight + HalfBoxHeight
-	return boxWidth, boxHeight, HalfBoxHeight, IncreasedBoxHeight
-}
-
-func DrawBox(screen tcell.Screen, x, y, width, height int, files []config.FileInfo, selectedIndex int, scrollPosition int, textStyle, highlightStyle tcell.Style, isFocused bool) {
+// DrawBox draws the entries of a box. With no columns only the names are drawn,
+// otherwise every entry is drawn as an aligned list view row. Names are colored
+// by colors unless the entry is highlighted, and entries whose absolute path is
+// in marked get a marker in front of them. prefixes, when given, holds the
+// tree view guides drawn in front of each name.
+func DrawBox(screen tcell.Screen, x, y, width, height int, files []config.FileInfo, selectedIndex int, scrollPosition int, textStyle, highlightStyle tcell.Style, isFocused bool, columns []string, colors LSColors, marked map[string]bool, prefixes []string) {
 	maxLines := height - 2
+	rowWidth := width - 4
+	var widths []int
+	if len(columns) > 0 {
+		columns, widths = FitColumns(columns, ColumnWidths(files, columns), rowWidth)
+	}
 	for i := scrollPosition; i < len(files) && i < scrollPosition+maxLines; i++ {
 		file := files[i]
 		style := textStyle
+		nameStyle := colors.StyleFor(file, textStyle)
 		if isFocused && i == selectedIndex {
 			style = highlightStyle
+			nameStyle = highlightStyle
 		}
 		lineY := y + (i - scrollPosition) + 1
-		for j, r := range file.Name {
-			if x+3+j >= x+width {
-				break
+		if len(marked) > 0 {
+			if path, err := filepath.Abs(file.Path); err == nil && marked[path] {
+				screen.SetContent(x+1, lineY, '+', nil, highlightStyle)
 			}
-			screen.SetContent(x+3+j, lineY, r, nil, style)
 		}
+		if len(columns) > 0 {
+			cellX := x + 3
+			for j, cell := range FormatRow(file, columns, widths, rowWidth) {
+				cellStyle := style
+				if columns[j] == "name" {
+					cellStyle = nameStyle
+					if prefixes != nil {
+						cellX += displayText(screen, cellX, lineY, prefixes[i], textStyle, x+3+rowWidth-cellX)
+					}
+				}
+				displayText(screen, cellX, lineY, cell, cellStyle, x+3+rowWidth-cellX)
+				cellX += len([]rune(cell)) + 1
+			}
+			continue
+		}
+		nameX := x + 3
+		if prefixes != nil {
+			nameX += displayText(screen, nameX, lineY, prefixes[i], textStyle, rowWidth)
+		}
+		displayText(screen, nameX, lineY, file.Name, nameStyle, x+3+rowWidth-nameX)
+	}
+}
+
+// DrawTitles draws the box titles. filesNote is appended to the Files title and
+// is used to show how many entries the file filters left out.
+func DrawTitles(screen tcell.Screen, layout Layout, filesNote string, style tcell.Style) {
+	titles := slices.Clone(Titles)
+	if filesNote != "" {
+		titles[1] += " " + filesNote
+	}
+	for i, title := range titles {
+		DrawRectTitle(screen, layout.Boxes[i], title, style)
+	}
+	DrawRectTitle(screen, layout.Parent, "Parent", style)
+	DrawRectTitle(screen, layout.Preview, "Preview", style)
+}
+
+// DrawRectTitle draws title on the top border of r.
+func DrawRectTitle(screen tcell.Screen, r Rect, title string, style tcell.Style) {
+	if r.Empty() {
+		return
+	}
+	displayText(screen, r.X+1, r.Y, title, style, r.Width-2)
+}
+
+// DrawTabBar draws the tab titles on row y, the active one highlighted.
+// Titles that do not fit are left out.
+func DrawTabBar(screen tcell.Screen, y, width int, titles []string, active int, style, activeStyle tcell.Style) {
+	x := 0
+	for i, title := range titles {
+		tabStyle := style
+		if i == active {
+			tabStyle = activeStyle.Reverse(true)
+		}
+		label := " " + title + " "
+		if x+len([]rune(label)) > width {
+			break
+		}
+		x += displayText(screen, x, y, label, tabStyle, width-x)
+		x++
 	}
 }
 
-func DrawTitles(screen tcell.Screen, x, y, width, height int, unused string, style tcell.Style) {
-	boxWidth, _, _, increasedBoxHeight := CalculateBoxDimensions(width, height)
-	titles := []string{"Directories", "Files", "Search", "File Info"}
+// DrawStatusBar draws left at the start of row y and right at its end. left
+// is cut off where it would run into right.
+func DrawStatusBar(screen tcell.Screen, y, width int, left, right string, style, rightStyle tcell.Style) {
+	rightWidth := len([]rune(right))
+	if rightWidth > 0 {
+		rightWidth = min(rightWidth, width-2)
+		displayText(screen, width-1-rightWidth, y, right, rightStyle, rightWidth)
+		rightWidth += 2
+	}
+	displayText(screen, 1, y, left, style, width-2-rightWidth)
+}
 
+// TabAt returns the index of the tab drawn at column x by DrawTabBar, or -1
+// when there is none.
+func TabAt(titles []string, x int) int {
+	start := 0
 	for i, title := range titles {
-		var tx, ty int
-		switch i {
-		case 0:
-			tx, ty = 1, 0
-		case 1:
-			tx, ty = boxWidth+1, 0
-		case 2:
-			tx, ty = 1, increasedBoxHeight
-		case 3:
-			tx, ty = boxWidth+1, increasedBoxHeight
+		end := start + len([]rune(title)) + 2
+		if x >= start && x < end {
+			return i
 		}
-		for j, r := range title {
-			screen.SetContent(tx+j, ty, r, nil, style)
+		start = end + 1
+	}
+	return -1
+}
+
+// PopupStyles returns the configured text and border styles that popups and
+// dialogs are drawn with.
+func PopupStyles(cfg *config.Config) (style, borderStyle tcell.Style) {
+	style = tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Text))
+	borderStyle = tcell.StyleDefault.Foreground(tcell.GetColor(cfg.Colors.Border))
+	return style, borderStyle
+}
+
+// DrawProgress draws a centered popup with a progress bar for done out of
+// total bytes, with the name of the file being worked on below it.
+func DrawProgress(screen tcell.Screen, title, current string, done, total int64, style, borderStyle tcell.Style) {
+	width, height := screen.Size()
+	r := Rect{X: width / 6, Y: height/2 - 3, Width: width * 2 / 3, Height: 6}
+	clearArea(screen, r.X, r.Y, r.X+r.Width-1, r.Y+r.Height-1)
+	DrawRect(screen, r, borderStyle)
+	DrawRectTitle(screen, r, title, style)
+
+	barWidth := r.Width - 4
+	filled := barWidth
+	percent := 100
+	if total > 0 {
+		filled = int(int64(barWidth) * min(done, total) / total)
+		percent = int(100 * min(done, total) / total)
+	}
+	for i := 0; i < barWidth; i++ {
+		ch := '░'
+		if i < filled {
+			ch = '█'
 		}
+		screen.SetContent(r.X+2+i, r.Y+2, ch, nil, style)
+	}
+	status := fmt.Sprintf("%d%%  %s / %s  %s", percent, formatFileSize(done), formatFileSize(total), current)
+	displayText(screen, r.X+2, r.Y+3, status, style, barWidth)
+}
+
+// DrawRect draws the border of r.
+func DrawRect(screen tcell.Screen, r Rect, style tcell.Style) {
+	if r.Empty() {
+		return
 	}
+	DrawBorder(screen, r.X, r.Y, r.X+r.Width-1, r.Y+r.Height-1, style)
 }
 
 func DrawText(screen tcell.Screen, x, y int, text string) {
@@ -108,23 +216,33 @@ func DrawText(screen tcell.Screen, x, y int, text string) {
 	}
 }
 
-func DrawFileContents(screen tcell.Screen, x, y, boxWidth, boxHeight int, file config.FileInfo, style tcell.Style) {
-	fileContents, err := fileops.ReadFileContents(file.Name)
-	if err != nil {
-		displayText(screen, x+1, y+1, fmt.Sprintf("Error reading file: %v", err), style, boxWidth-3)
-		return
+// PreviewLineLimit is the number of lines of a file read for the preview.
+const PreviewLineLimit = 10000
+
+// DrawFileContents draws the lines of a file starting at line offset, followed
+// by a note when the file was only read in part. It returns the offset used,
+// which is kept from scrolling past the end of the file.
+func DrawFileContents(screen tcell.Screen, x, y, boxWidth, boxHeight int, contents fileops.FileContents, offset int, style tcell.Style) int {
+	if contents.Err != nil {
+		displayText(screen, x+1, y+1, fmt.Sprintf("Error reading file: %v", contents.Err), style, boxWidth-3)
+		return 0
+	}
+	lines := contents.Lines
+	if contents.Truncated {
+		lines = append(lines[:len(lines):len(lines)], fmt.Sprintf("[preview truncated after %d lines]", len(lines)))
 	}
-	lines := strings.Split(fileContents, "\n")
 	contentX := x + 1
 	contentWidth := boxWidth - 3
 	maxLines := boxHeight - 2
+	offset = max(min(offset, len(lines)-maxLines), 0)
 
-	for i, line := range lines {
+	for i, line := range lines[offset:] {
 		if i >= maxLines {
 			break
 		}
 		displayText(screen, contentX, y+1+i, line, style, contentWidth)
 	}
+	return offset
 }
 
 func DrawTitle(screen tcell.Screen, title string) {
@@ -147,7 +265,93 @@ func DrawPrompt(screen tcell.Screen, prompt string) {
 	screen.Show()
 }
 
-func DrawASCIIArt(screen tcell.Screen) {
+// DrawCompletions draws a dropdown with the completion candidates right below
+// the box drawn by DrawPrompt, highlighting the selected one.
+func DrawCompletions(screen tcell.Screen, items []string, selected int) {
+	if len(items) == 0 {
+		return
+	}
+	width, height := screen.Size()
+	boxWidth := width / 2
+	x1 := (width - boxWidth) / 2
+	y1 := (height-height/4)/2 + height/4
+	x2 := x1 + boxWidth - 1
+	y2 := min(y1+len(items)+1, height-1)
+	style := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+
+	clearArea(screen, x1, y1, x2, y2)
+	DrawBorder(screen, x1, y1, x2, y2, style)
+	maxItems := y2 - y1 - 1
+	scroll := max(selected-maxItems+1, 0)
+	for i := scroll; i < len(items) && i-scroll < maxItems; i++ {
+		itemStyle := style
+		if i == selected {
+			itemStyle = style.Reverse(true)
+		}
+		displayText(screen, x1+2, y1+1+i-scroll, items[i], itemStyle, boxWidth-4)
+	}
+}
+
+// DrawListPopup draws a centered popup on top of the current screen, with the
+// header line (usually the typed input) above the items. The selected item is
+// drawn reversed and the list scrolls to keep it visible.
+func DrawListPopup(screen tcell.Screen, title, header string, items []string, selected int, style, borderStyle tcell.Style) {
+	width, height := screen.Size()
+	popupWidth := width * 2 / 3
+	popupHeight := height * 2 / 3
+	x1 := (width - popupWidth) / 2
+	y1 := (height - popupHeight) / 2
+	x2 := x1 + popupWidth - 1
+	y2 := y1 + popupHeight - 1
+
+	clearArea(screen, x1, y1, x2, y2)
+	DrawBorder(screen, x1, y1, x2, y2, borderStyle)
+	displayText(screen, x1+1, y1, title, style, popupWidth-2)
+	displayText(screen, x1+2, y1+1, header, style, popupWidth-4)
+
+	maxItems := popupHeight - 3
+	scroll := max(selected-maxItems+1, 0)
+	for i := scroll; i < len(items) && i-scroll < maxItems; i++ {
+		itemStyle := style
+		if i == selected {
+			itemStyle = style.Reverse(true)
+		}
+		displayText(screen, x1+2, y1+2+i-scroll, items[i], itemStyle, popupWidth-4)
+	}
+}
+
+// DrawTextPopup draws a popup like DrawListPopup showing lines from scroll
+// on. Lines that are not indented are headings and drawn in bold.
+func DrawTextPopup(screen tcell.Screen, title string, lines []string, scroll int, style, borderStyle tcell.Style) {
+	width, height := screen.Size()
+	popupWidth := width * 2 / 3
+	popupHeight := height * 2 / 3
+	x1 := (width - popupWidth) / 2
+	y1 := (height - popupHeight) / 2
+
+	clearArea(screen, x1, y1, x1+popupWidth-1, y1+popupHeight-1)
+	DrawBorder(screen, x1, y1, x1+popupWidth-1, y1+popupHeight-1, borderStyle)
+	displayText(screen, x1+1, y1, title, style, popupWidth-2)
+	for i := scroll; i < len(lines) && i-scroll < popupHeight-2; i++ {
+		lineStyle := style
+		if lines[i] != "" && lines[i][0] != ' ' {
+			lineStyle = style.Bold(true)
+		}
+		displayText(screen, x1+2, y1+1+i-scroll, lines[i], lineStyle, popupWidth-4)
+	}
+}
+
+func clearArea(screen tcell.Screen, x1, y1, x2, y2 int) {
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			screen.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+		}
+	}
+}
+
+// DrawASCIIArt draws the logo in the bottom right corner of r, as long as it
+// leaves room for the file info next to it.
+func DrawASCIIArt(screen tcell.Screen, r Rect) {
 	cfg, err := GetConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
@@ -169,8 +373,11 @@ ___     _____   _____
 			maxWidth = len(line)
 		}
 	}
-	asciiArtX := width - maxWidth - 2
-	asciiArtY := height - len(asciiArtLines) - 2
+	if r.Width < maxWidth+30 || r.Height < len(asciiArtLines) {
+		return
+	}
+	asciiArtX := r.X + r.Width - maxWidth - 2
+	asciiArtY := r.Y + r.Height - len(asciiArtLines)
 
 	for i, line := range asciiArtLines {
 		for j, r := range line {
@@ -181,18 +388,23 @@ ___     _____   _____
 	}
 }
 
-func displayText(screen tcell.Screen, startX, y int, text string, style tcell.Style, maxWidth int) {
+// displayText draws text from startX, cut off after maxWidth cells or at the
+// screen edge, and returns the number of cells it used.
+func displayText(screen tcell.Screen, startX, y int, text string, style tcell.Style, maxWidth int) int {
 	screenWidth, screenHeight := screen.Size()
 	if y >= screenHeight || startX >= screenWidth {
-		return
+		return 0
 	}
-	for i, r := range text {
+	i := 0
+	for _, r := range text {
 		x := startX + i
 		if x >= screenWidth || i >= maxWidth {
 			break
 		}
 		screen.SetContent(x, y, r, nil, style)
+		i++
 	}
+	return i
 }
 
 func truncateString(s string, maxLen int) string {
@@ -223,7 +435,7 @@ func formatFileSize(size int64) string {
 	return fmt.Sprintf("%.1f %s", float64(size)/float64(div), units[exp])
 }
 
-func DisplayFileInfo(screen tcell.Screen, x, y, maxWidth int, file config.FileInfo, labelStyle, valueStyle tcell.Style) {
+func DisplayFileInfo(screen tcell.Screen, x, y, maxWidth, maxHeight int, file config.FileInfo, labelStyle, valueStyle tcell.Style) {
 	if screen == nil {
 		return
 	}
@@ -247,9 +459,9 @@ func DisplayFileInfo(screen tcell.Screen, x, y, maxWidth int, file config.FileIn
 		{"Git Status:", file.GitRepoStatus},
 	}
 	currentY := y
-	maxDisplayHeight := screenHeight - y - 1
+	maxHeight = min(maxHeight, screenHeight-y)
 	for i, item := range infoItems {
-		if i >= maxDisplayHeight {
+		if i >= maxHeight {
 			break
 		}
 		labelWidth := len(item.label) + 1
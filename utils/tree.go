@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"lds/config"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tree keeps track of which directories are expanded in the tree view of the
+// Directories box. Children are read the first time a directory is expanded
+// and cached until Refresh is called. The cache holds the unfiltered listing,
+// so changes to the filters and sort order show up on the next Flatten.
+type Tree struct {
+	expanded map[string]bool
+	children map[string]treeListing
+}
+
+// treeListing holds the subdirectories of a directory, with the hidden ones
+// kept apart for ApplyFileFilters.
+type treeListing struct {
+	directories []config.FileInfo
+	hidden      []config.FileInfo
+}
+
+// TreeEntry is one row of the flattened tree.
+type TreeEntry struct {
+	File     config.FileInfo
+	Depth    int
+	Expanded bool
+	// Prefix holds the indentation guides and the expand marker drawn in front
+	// of the name.
+	Prefix string
+}
+
+func NewTree() *Tree {
+	return &Tree{expanded: map[string]bool{}, children: map[string]treeListing{}}
+}
+
+// Refresh drops the cached children so they are read again, keeping the
+// expanded directories expanded.
+func (t *Tree) Refresh() {
+	t.children = map[string]treeListing{}
+}
+
+func (t *Tree) IsExpanded(path string) bool {
+	return t.expanded[path]
+}
+
+func (t *Tree) Expand(path string) {
+	t.expanded[path] = true
+}
+
+// Collapse collapses path and every directory below it.
+func (t *Tree) Collapse(path string) {
+	for p := range t.expanded {
+		if p == path || IsWithin(p, path) {
+			delete(t.expanded, p)
+		}
+	}
+}
+
+// Flatten returns the rows of the tree below roots, descending into the
+// expanded directories. Children go through the same file filters as the top
+// level and are sorted by sortBy, as SortFiles does.
+func (t *Tree) Flatten(roots []config.FileInfo, cfg *config.Config, sortBy string, reverse bool) []TreeEntry {
+	var entries []TreeEntry
+	t.flatten(&entries, roots, 0, "", cfg, sortBy, reverse)
+	return entries
+}
+
+func (t *Tree) flatten(entries *[]TreeEntry, dirs []config.FileInfo, depth int, guides string, cfg *config.Config, sortBy string, reverse bool) {
+	for i, dir := range dirs {
+		last := i == len(dirs)-1
+		prefix := guides
+		if depth > 0 {
+			if last {
+				prefix += "└─"
+			} else {
+				prefix += "├─"
+			}
+		}
+		expanded := t.expanded[dir.Path]
+		if expanded {
+			prefix += "▾ "
+		} else {
+			prefix += "▸ "
+		}
+		*entries = append(*entries, TreeEntry{File: dir, Depth: depth, Expanded: expanded, Prefix: prefix})
+		if !expanded {
+			continue
+		}
+
+		childGuides := guides
+		if depth > 0 {
+			if last {
+				childGuides += "  "
+			} else {
+				childGuides += "│ "
+			}
+		}
+		t.flatten(entries, t.childrenOf(dir.Path, cfg, sortBy, reverse), depth+1, childGuides, cfg, sortBy, reverse)
+	}
+}
+
+// childrenOf returns the visible subdirectories of dir in the given sort
+// order, reading them on first use.
+func (t *Tree) childrenOf(dir string, cfg *config.Config, sortBy string, reverse bool) []config.FileInfo {
+	listing, ok := t.children[dir]
+	if !ok {
+		listing = readSubdirectories(dir, cfg.FileFilters.HideGitIgnored)
+		t.children[dir] = listing
+	}
+	children, _, _ := ApplyFileFilters(listing.directories, nil, listing.hidden, cfg)
+	SortFiles(children, sortBy, reverse)
+	return children
+}
+
+// readSubdirectories lists the directories in dir. Unlike ReadDirectory it
+// skips the mount point and SELinux lookups, which would run a subprocess for
+// every child. Git is only asked about ignored entries when gitIgnored is set.
+func readSubdirectories(dir string, gitIgnored bool) treeListing {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		log.Println("Error reading directory:", err)
+	}
+
+	var listing treeListing
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		names = append(names, info.Name())
+		entry := newFileInfo(filepath.Join(dir, info.Name()), info, GitStatusDetail)
+		if isHidden(info) {
+			listing.hidden = append(listing.hidden, entry)
+		} else {
+			listing.directories = append(listing.directories, entry)
+		}
+	}
+
+	if !gitIgnored {
+		return listing
+	}
+	ignored := getGitIgnored(dir, names)
+	for _, entries := range [][]config.FileInfo{listing.directories, listing.hidden} {
+		for i := range entries {
+			entries[i].IsGitIgnored = ignored[entries[i].Name]
+		}
+	}
+	return listing
+}
+
+// IsWithin reports whether path lies below dir.
+func IsWithin(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
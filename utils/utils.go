@@ -1,13 +1,19 @@
 package utils
 
 import (
+	"cmp"
 	"fmt"
 	"lds/config"
+	"lds/logging"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -23,6 +29,64 @@ func ExpandPath(path string) (string, error) {
 	return path, nil
 }
 
+// CompletePath returns the entries that complete the last element of the
+// partially typed path input. Candidates keep the input's own prefix, so "~/Do"
+// completes to "~/Documents/", and directories end with a separator. Dotfiles
+// are only offered once the element being completed starts with a dot.
+func CompletePath(input string) []string {
+	dirPart, base := "", input
+	if i := strings.LastIndexAny(input, `/`+string(filepath.Separator)); i >= 0 {
+		dirPart, base = input[:i+1], input[i+1:]
+	} else if input == "~" {
+		return []string{"~" + string(filepath.Separator)}
+	}
+
+	dir := dirPart
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := ExpandPath(dir)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (isDotFile(name) && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dirPart + name
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+			candidate += string(filepath.Separator)
+		}
+		candidates = append(candidates, candidate)
+	}
+	slices.Sort(candidates)
+	return candidates
+}
+
+// CommonPrefix returns the longest prefix shared by all of items.
+func CommonPrefix(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	prefix := items[0]
+	for _, item := range items[1:] {
+		for !strings.HasPrefix(item, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
 func GetFileType(info os.FileInfo) string {
 	switch mode := info.Mode(); {
 	case mode.IsRegular():
@@ -35,8 +99,10 @@ func GetFileType(info os.FileInfo) string {
 		return "Named Pipe"
 	case mode&os.ModeSocket != 0:
 		return "Socket"
+	case mode&os.ModeCharDevice != 0:
+		return "Character Device"
 	case mode&os.ModeDevice != 0:
-		return "Device"
+		return "Block Device"
 	default:
 		return "Unknown"
 	}
@@ -55,9 +121,198 @@ func GetLastModified(modTime time.Time) string {
 	}
 }
 
+// Details selects the FileInfo fields that take a subprocess per entry to fill
+// in, so that listings can skip the ones they do not show.
+type Details uint8
+
+const (
+	MountPointDetail Details = 1 << iota
+	SELinuxDetail
+	GitStatusDetail
+	// GitIgnoredDetail runs a single git process for the whole directory.
+	GitIgnoredDetail
+
+	AllDetails = MountPointDetail | SELinuxDetail | GitStatusDetail | GitIgnoredDetail
+)
+
+// DetailsFor returns the details the interface shows with cfg. Whether an
+// entry is ignored by git is only looked up while such entries are hidden.
+func DetailsFor(cfg *config.Config) Details {
+	details := AllDetails &^ GitIgnoredDetail
+	if cfg.FileFilters.HideGitIgnored {
+		details |= GitIgnoredDetail
+	}
+	return details
+}
+
+// ReadDirectory reads dir and sorts its entries into directories, regular files
+// and hidden entries. The Path of every entry is dir joined with its name.
+func ReadDirectory(dir string) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, error) {
+	return ReadDirectoryDetails(dir, AllDetails)
+}
+
+// ReadDirectoryDetails is ReadDirectory filling in only the given details.
+func ReadDirectoryDetails(dir string, details Details) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var directories, regularFiles, hiddenFiles []config.FileInfo
+
+	var gitIgnored map[string]bool
+	if details&GitIgnoredDetail != 0 {
+		names := make([]string, 0, len(files))
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		gitIgnored = getGitIgnored(dir, names)
+	}
+
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		fileInfo := newFileInfo(filepath.Join(dir, info.Name()), info, details)
+		fileInfo.IsGitIgnored = gitIgnored[info.Name()]
+
+		if isHidden(info) {
+			hiddenFiles = append(hiddenFiles, fileInfo)
+		} else if info.IsDir() {
+			directories = append(directories, fileInfo)
+		} else {
+			regularFiles = append(regularFiles, fileInfo)
+		}
+	}
+
+	return directories, regularFiles, hiddenFiles, nil
+}
+
+// StatFile extracts the FileInfo of a single path without following symlinks.
+func StatFile(path string) (config.FileInfo, error) {
+	return StatFileDetails(path, AllDetails)
+}
+
+// StatFileDetails is StatFile filling in only the given details.
+func StatFileDetails(path string, details Details) (config.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return config.FileInfo{}, err
+	}
+	return newFileInfo(path, info, details), nil
+}
+
+// PreviewDirectory lists dir for the parent and preview columns: the visible
+// directories followed by the visible files, sorted by sortBy as SortFiles does.
+// Only the fields needed to draw a name are filled in, which keeps it cheap
+// enough to call on every redraw.
+func PreviewDirectory(dir string, cfg *config.Config, sortBy string, reverse bool) ([]config.FileInfo, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var directories, regularFiles, hiddenFiles []config.FileInfo
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, info.Name())
+		isSymlink := info.Mode()&os.ModeSymlink != 0
+		entry := config.FileInfo{
+			Name:            info.Name(),
+			Path:            path,
+			FileType:        GetFileType(info),
+			IsExecutable:    info.Mode()&0111 != 0,
+			IsSymlink:       isSymlink,
+			IsBrokenSymlink: isSymlink && isBrokenSymlink(path),
+			ModTime:         info.ModTime(),
+			Size:            info.Size(),
+		}
+		if isHidden(info) {
+			hiddenFiles = append(hiddenFiles, entry)
+		} else if info.IsDir() {
+			directories = append(directories, entry)
+		} else {
+			regularFiles = append(regularFiles, entry)
+		}
+	}
+	directories, regularFiles, _ = ApplyFileFilters(directories, regularFiles, hiddenFiles, cfg)
+	SortFiles(directories, sortBy, reverse)
+	SortFiles(regularFiles, sortBy, reverse)
+	return append(directories, regularFiles...), nil
+}
+
+func ReadDirectoryAndUpdateBestMatch(screen tcell.Screen, query string, details Details) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, *config.FileInfo) {
+	directories, regularFiles, hiddenFiles, err := ReadDirectoryDetails(".", details)
+	if err != nil {
+		logging.LogErrorAndExit("Error reading directory", err)
+	}
+
+	filteredDirectories := FilterFiles(directories, query)
+	filteredFiles := FilterFiles(regularFiles, query)
+	filteredHiddenFiles := FilterFiles(hiddenFiles, query)
+	bestMatch := FindBestMatch(filteredDirectories, filteredFiles, filteredHiddenFiles, query)
+
+	return filteredDirectories, filteredFiles, filteredHiddenFiles, bestMatch
+}
+
+// ChangeDirectory changes the working directory of lds to directory, or to its
+// parent when up is set. Navigation happens in-process so that the last visited
+// directory can be handed back to the shell on exit.
+func ChangeDirectory(directory string, up bool) error {
+	if up {
+		return os.Chdir("..")
+	}
+	return os.Chdir(filepath.Clean(directory))
+}
+
+// SortModes lists the values accepted by the sort.by config option, in the
+// order the sort key cycles through them.
+var SortModes = []string{"name", "size", "time", "extension"}
+
+// NextSortMode returns the sort mode following mode.
+func NextSortMode(mode string) string {
+	for i, m := range SortModes {
+		if m == mode {
+			return SortModes[(i+1)%len(SortModes)]
+		}
+	}
+	return SortModes[1]
+}
+
+// SortFiles sorts files in place. Names are compared case-insensitively and
+// break ties for the other modes. Sizes and times sort largest and newest first
+// like ls does, reverse flips the whole order.
+func SortFiles(files []config.FileInfo, by string, reverse bool) {
+	byName := func(a, b config.FileInfo) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	slices.SortStableFunc(files, func(a, b config.FileInfo) int {
+		result := 0
+		switch by {
+		case "size":
+			result = cmp.Compare(b.Size, a.Size)
+		case "time":
+			result = b.ModTime.Compare(a.ModTime)
+		case "extension":
+			result = strings.Compare(strings.ToLower(filepath.Ext(a.Name)), strings.ToLower(filepath.Ext(b.Name)))
+		}
+		if result == 0 {
+			result = byName(a, b)
+		}
+		if reverse {
+			result = -result
+		}
+		return result
+	})
+}
+
 type FileSystemProvider interface {
-	ReadDirectoryAndUpdateBestMatch(screen tcell.Screen, query string) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, *config.FileInfo)
-	ChangeDirectoryAndRerun(directory string, up bool)
+	ReadDirectoryAndUpdateBestMatch(screen tcell.Screen, query string, details Details) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, *config.FileInfo)
+	ChangeDirectory(directory string, up bool) error
 	GetFileType(info os.FileInfo) string
 	GetLastModified(modTime time.Time) string
 	ExpandPath(path string) (string, error)
@@ -87,3 +342,130 @@ func FindBestMatch(directories, files, hiddenFiles []config.FileInfo, query stri
 	}
 	return bestMatch
 }
+
+// ApplyFileFilters applies the fileFilters config to a directory listing. Hidden
+// entries are only kept when ShowHiddenFiles is set, git-ignored entries are
+// dropped when HideGitIgnored is set, and the extension lists only apply to
+// entries that are not directories. The number of entries that were left out
+// is returned alongside the visible directories and files.
+func ApplyFileFilters(directories, regularFiles, hiddenFiles []config.FileInfo, cfg *config.Config) ([]config.FileInfo, []config.FileInfo, int) {
+	filters := cfg.FileFilters
+	var visibleDirectories, visibleFiles []config.FileInfo
+	filteredOut := 0
+
+	keep := func(file config.FileInfo, hidden bool) {
+		if hidden && !filters.ShowHiddenFiles {
+			filteredOut++
+			return
+		}
+		if file.IsGitIgnored && filters.HideGitIgnored && !filters.ShowHiddenFiles {
+			filteredOut++
+			return
+		}
+		if file.FileType == "Directory" {
+			visibleDirectories = append(visibleDirectories, file)
+			return
+		}
+		if !matchesExtensionFilters(file.Name, filters.FileExtensions, filters.ExcludeExtensions) {
+			filteredOut++
+			return
+		}
+		visibleFiles = append(visibleFiles, file)
+	}
+
+	for _, file := range hiddenFiles {
+		keep(file, true)
+	}
+	for _, file := range directories {
+		keep(file, false)
+	}
+	for _, file := range regularFiles {
+		keep(file, false)
+	}
+
+	return visibleDirectories, visibleFiles, filteredOut
+}
+
+func matchesExtensionFilters(name string, include, exclude []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range exclude {
+		if ext == normalizeExtension(e) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, e := range include {
+		if ext == normalizeExtension(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func isDotFile(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// getGitIgnored asks git which of the given names in dir are matched by a
+// .gitignore rule. Outside a repository nothing is ignored. The names are
+// passed on stdin so that large directories do not run into ARG_MAX.
+func getGitIgnored(dir string, names []string) map[string]bool {
+	ignored := make(map[string]bool)
+	if len(names) == 0 {
+		return ignored
+	}
+	cmd := exec.Command("git", "-C", dir, "check-ignore", "--stdin", "-z")
+	cmd.Stdin = strings.NewReader(strings.Join(names, "\x00") + "\x00")
+	// check-ignore exits with 1 when nothing matches, so only the output matters
+	output, _ := cmd.Output()
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name != "" {
+			ignored[name] = true
+		}
+	}
+	return ignored
+}
+
+// isBrokenSymlink reports whether the target of a symlink can no longer be
+// resolved.
+func isBrokenSymlink(name string) bool {
+	_, err := os.Stat(name)
+	return err != nil
+}
+
+// FuzzyMatch reports whether the runes of query appear in text in the same
+// order, ignoring case, and scores the match. Runes that follow each other or
+// start a word score higher.
+func FuzzyMatch(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	score, matched, previous := 0, 0, -2
+	for i, r := range t {
+		if matched == len(q) {
+			break
+		}
+		if r != q[matched] {
+			continue
+		}
+		score++
+		if i == previous+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		previous = i
+		matched++
+	}
+	return score, matched == len(q)
+}
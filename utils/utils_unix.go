@@ -5,7 +5,6 @@ package utils
 import (
 	"fmt"
 	"lds/config"
-	"lds/logging"
 	"os"
 	"os/exec"
 	"os/user"
@@ -13,40 +12,12 @@ import (
 	"strings"
 	"syscall"
 	"time"
-
-	"github.com/gdamore/tcell/v2"
 )
 
-func ChangeDirectoryAndRerun(directory string, up bool) {
-	var cmdStr string
-
-	if up {
-		cmdStr = "cd .. && lds"
-	} else {
-		cleanDir := filepath.Clean(directory)
-		cmdStr = fmt.Sprintf("cd %q && lds", cleanDir)
-	}
-
-	cmd := exec.Command("sh", "-c", cmdStr)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to change directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	os.Exit(0)
-}
-
-func extractFileInfo(info os.FileInfo) (lastAccessTime, creationTime string, size int64, fileType string, inode uint64, hardLinksCount uint64) {
+func extractFileInfo(info os.FileInfo) (lastAccess, creation time.Time, size int64, fileType string, inode uint64, hardLinksCount uint64) {
 	stat := info.Sys().(*syscall.Stat_t)
 
-	lastAccess, creation := getTimeInfo(stat)
-
-	lastAccessTime = GetLastModified(lastAccess)
-	creationTime = GetLastModified(creation)
+	lastAccess, creation = getTimeInfo(stat)
 	size = info.Size()
 	fileType = GetFileType(info)
 	inode = stat.Ino
@@ -55,59 +26,49 @@ func extractFileInfo(info os.FileInfo) (lastAccessTime, creationTime string, siz
 	return
 }
 
-func ReadDirectoryAndUpdateBestMatch(screen tcell.Screen, query string) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, *config.FileInfo) {
-	files, err := os.ReadDir(".")
-	if err != nil {
-		logging.LogErrorAndExit("Error reading directory", err)
-	}
-
-	var directories, regularFiles []config.FileInfo
-
-	for _, file := range files {
-		info, err := file.Info()
-		if err != nil {
-			continue
-		}
+func newFileInfo(path string, info os.FileInfo, details Details) config.FileInfo {
+	lastAccess, creation, size, fileType, inode, hardLinksCount := extractFileInfo(info)
 
-		lastAccessTime, creationTime, size, fileType, inode, hardLinksCount := extractFileInfo(info)
-
-		stat := info.Sys().(*syscall.Stat_t)
-		owner := getOwnerInfo(stat)
-
-		isSymlink, symlinkTarget := getSymlinkStatus(file)
-
-		isExecutable := info.Mode()&0111 != 0
-
-		fileInfo := config.FileInfo{
-			Name:           info.Name(),
-			Permissions:    info.Mode().String(),
-			Owner:          owner,
-			IsExecutable:   isExecutable,
-			IsSymlink:      isSymlink,
-			SymlinkTarget:  symlinkTarget,
-			MountPoint:     getMountPoint(info),
-			SELinuxContext: getSELinuxContext(info),
-			GitRepoStatus:  getGitRepoStatus(file),
-			LastAccessTime: lastAccessTime,
-			CreationTime:   creationTime,
-			Size:           size,
-			FileType:       fileType,
-			Inode:          inode,
-			HardLinksCount: hardLinksCount,
-		}
-
-		if info.IsDir() {
-			directories = append(directories, fileInfo)
-		} else {
-			regularFiles = append(regularFiles, fileInfo)
-		}
+	stat := info.Sys().(*syscall.Stat_t)
+	owner := getOwnerInfo(stat)
+
+	isSymlink, symlinkTarget := getSymlinkStatus(path, info)
+
+	isExecutable := info.Mode()&0111 != 0
+
+	file := config.FileInfo{
+		Name:            info.Name(),
+		Path:            path,
+		Permissions:     info.Mode().String(),
+		Owner:           owner,
+		IsExecutable:    isExecutable,
+		IsSymlink:       isSymlink,
+		SymlinkTarget:   symlinkTarget,
+		IsBrokenSymlink: isSymlink && isBrokenSymlink(path),
+		LastAccessTime:  GetLastModified(lastAccess),
+		CreationTime:    GetLastModified(creation),
+		AccessTime:      lastAccess,
+		CreateTime:      creation,
+		ModTime:         info.ModTime(),
+		Size:            size,
+		FileType:        fileType,
+		Inode:           inode,
+		HardLinksCount:  hardLinksCount,
 	}
+	if details&MountPointDetail != 0 {
+		file.MountPoint = getMountPoint(path)
+	}
+	if details&SELinuxDetail != 0 {
+		file.SELinuxContext = getSELinuxContext(path)
+	}
+	if details&GitStatusDetail != 0 {
+		file.GitRepoStatus = getGitRepoStatus(path, info.IsDir())
+	}
+	return file
+}
 
-	filteredDirectories := FilterFiles(directories, query)
-	filteredFiles := FilterFiles(regularFiles, query)
-	bestMatch := FindBestMatch(filteredDirectories, filteredFiles, nil, query)
-
-	return filteredDirectories, filteredFiles, nil, bestMatch
+func isHidden(info os.FileInfo) bool {
+	return isDotFile(info.Name())
 }
 
 func getOwnerInfo(stat *syscall.Stat_t) string {
@@ -132,9 +93,9 @@ func getOwnerInfo(stat *syscall.Stat_t) string {
 	return fmt.Sprintf("%s:%s", username, groupname)
 }
 
-func getSymlinkStatus(file os.DirEntry) (bool, string) {
-	if file.Type()&os.ModeSymlink != 0 {
-		target, err := os.Readlink(file.Name())
+func getSymlinkStatus(path string, info os.FileInfo) (bool, string) {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
 		if err != nil {
 			return true, "unknown"
 		}
@@ -143,8 +104,8 @@ func getSymlinkStatus(file os.DirEntry) (bool, string) {
 	return false, ""
 }
 
-func getMountPoint(info os.FileInfo) string {
-	cmd := exec.Command("findmnt", "-n", "-o", "TARGET", "--target", info.Name())
+func getMountPoint(path string) string {
+	cmd := exec.Command("findmnt", "-n", "-o", "TARGET", "--target", path)
 	output, err := cmd.Output()
 	if err != nil {
 		return "N/A"
@@ -152,28 +113,28 @@ func getMountPoint(info os.FileInfo) string {
 	return strings.TrimSpace(string(output))
 }
 
-func getSELinuxContext(info os.FileInfo) string {
-	cmd := exec.Command("ls", "-Z", info.Name())
+func getSELinuxContext(path string) string {
+	cmd := exec.Command("ls", "-dZ", path)
 	output, err := cmd.Output()
 	if err != nil {
 		return "N/A"
 	}
 	parts := strings.Fields(string(output))
-	if len(parts) > 3 {
-		return parts[3] // SELinux context is usually the 4th field
+	if len(parts) > 1 && parts[0] != "?" {
+		return parts[0] // ls -dZ prints the context followed by the path
 	}
 	return "N/A"
 }
 
-func getGitRepoStatus(file os.DirEntry) string {
-	if file.IsDir() {
-		gitDir := fmt.Sprintf("%s/.git", file.Name())
+func getGitRepoStatus(path string, isDir bool) string {
+	if isDir {
+		gitDir := filepath.Join(path, ".git")
 		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 			return "Not a git repository"
 		}
 		return "Git repository"
 	} else {
-		cmd := exec.Command("git", "status", "--porcelain", file.Name())
+		cmd := exec.Command("git", "-C", filepath.Dir(path), "status", "--porcelain", filepath.Base(path))
 		output, err := cmd.Output()
 		if err != nil {
 			return "Not a git repository"
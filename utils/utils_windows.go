@@ -5,12 +5,8 @@ package utils
 import (
 	"fmt"
 	"lds/config"
-	"lds/logging"
 	"os"
-	"os/exec"
-	"path/filepath"
-
-	"github.com/gdamore/tcell/v2"
+	"syscall"
 )
 
 type WindowsNavigator struct {
@@ -35,92 +31,46 @@ func NewWindowsNavigator() (*WindowsNavigator, error) {
 	}, nil
 }
 
-func ChangeDirectoryAndRerun(directory string, up bool) {
-	var targetDir string
-
-	if up {
-		targetDir = ".."
-	} else {
-		targetDir = filepath.Clean(directory)
-	}
-
-	absTargetDir, err := filepath.Abs(targetDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to resolve directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	execPath, err := os.Executable()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to get executable path: %v\n", err)
-		os.Exit(1)
-	}
-
-	cmd := exec.Command(execPath)
-	cmd.Dir = absTargetDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to change directory: %v\n", err)
-		os.Exit(1)
+func newFileInfo(path string, info os.FileInfo, _ Details) config.FileInfo {
+	var isExecutable, isSymlink bool
+	var symlinkTarget, gitRepoStatus, lastAccessTime, creationTime string
+	var size int64
+	var fileType string
+
+	isExecutable = false
+	isSymlink = false
+	symlinkTarget = "N/A"
+	gitRepoStatus = "N/A"
+	lastAccessTime = GetLastModified(info.ModTime())
+	creationTime = GetLastModified(info.ModTime())
+	size = info.Size()
+	fileType = GetFileType(info)
+
+	return config.FileInfo{
+		Name:           info.Name(),
+		Path:           path,
+		IsExecutable:   isExecutable,
+		IsSymlink:      isSymlink,
+		SymlinkTarget:  symlinkTarget,
+		GitRepoStatus:  gitRepoStatus,
+		LastAccessTime: lastAccessTime,
+		CreationTime:   creationTime,
+		AccessTime:     info.ModTime(),
+		CreateTime:     info.ModTime(),
+		ModTime:        info.ModTime(),
+		Size:           size,
+		FileType:       fileType,
 	}
-
-	os.Exit(0)
 }
 
-func ReadDirectoryAndUpdateBestMatch(screen tcell.Screen, query string) ([]config.FileInfo, []config.FileInfo, []config.FileInfo, *config.FileInfo) {
-	files, err := os.ReadDir(".")
-	if err != nil {
-		logging.LogErrorAndExit("Error reading directory", err)
+// isHidden treats both dotfiles and entries carrying the Windows hidden
+// attribute as hidden.
+func isHidden(info os.FileInfo) bool {
+	if isDotFile(info.Name()) {
+		return true
 	}
-
-	var directories, regularFiles []config.FileInfo
-
-	for _, file := range files {
-		info, err := file.Info()
-		if err != nil {
-			continue
-		}
-
-		var isExecutable, isSymlink bool
-		var symlinkTarget, gitRepoStatus, lastAccessTime, creationTime string
-		var size int64
-		var fileType string
-
-		isExecutable = false
-		isSymlink = false
-		symlinkTarget = "N/A"
-		gitRepoStatus = "N/A"
-		lastAccessTime = GetLastModified(info.ModTime())
-		creationTime = GetLastModified(info.ModTime())
-		size = info.Size()
-		fileType = GetFileType(info)
-
-		fileInfo := config.FileInfo{
-			Name:           info.Name(),
-			IsExecutable:   isExecutable,
-			IsSymlink:      isSymlink,
-			SymlinkTarget:  symlinkTarget,
-			GitRepoStatus:  gitRepoStatus,
-			LastAccessTime: lastAccessTime,
-			CreationTime:   creationTime,
-			Size:           size,
-			FileType:       fileType,
-		}
-
-		if info.IsDir() {
-			directories = append(directories, fileInfo)
-		} else {
-			regularFiles = append(regularFiles, fileInfo)
-		}
+	if attrs, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return attrs.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
 	}
-
-	filteredDirectories := FilterFiles(directories, query)
-	filteredFiles := FilterFiles(regularFiles, query)
-
-	bestMatch := FindBestMatch(filteredDirectories, filteredFiles, nil, query)
-
-	return filteredDirectories, filteredFiles, nil, bestMatch
+	return false
 }